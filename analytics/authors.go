@@ -0,0 +1,271 @@
+package analytics
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Contributor is a person named in a book's contributor string
+type Contributor struct {
+	Name string
+	// Role is what the person did, e.g. "illustrated" or "read",
+	// and empty for authors
+	Role string
+}
+
+// ParseContributor parses contributor strings such as
+// "by Clive Cussler and Justin Scott" or
+// "by Mo Willems; illustrated by Tony Fucile; read by the author"
+func ParseContributor(s string) []Contributor {
+	var out []Contributor
+	for _, clause := range strings.Split(s, ";") {
+		clause = strings.TrimSpace(clause)
+		idx := strings.Index(strings.ToLower(clause), "by ")
+		if idx < 0 || (idx > 0 && clause[idx-1] != ' ') {
+			continue
+		}
+		role := strings.TrimSpace(strings.ToLower(clause[:idx]))
+		if role == "written" {
+			role = ""
+		}
+
+		authors, with := SplitAuthors(clause[idx+len("by "):])
+		for _, name := range authors {
+			out = append(out, Contributor{Name: name, Role: role})
+		}
+		for _, name := range with {
+			out = append(out, Contributor{Name: name, Role: "with"})
+		}
+	}
+
+	return out
+}
+
+// SplitAuthors splits an author field such as "James Patterson and
+// Maxine Paetro" into names. Names given in a "with" clause, usually
+// co-writers, are returned apart.
+func SplitAuthors(s string) (authors, with []string) {
+	main := s
+	if i := strings.Index(strings.ToLower(s), " with "); i >= 0 {
+		main = s[:i]
+		with = splitNames(s[i+len(" with "):])
+	}
+
+	return splitNames(main), with
+}
+
+func splitNames(s string) []string {
+	// a single "Last, First" name
+	if parts := strings.Split(s, ","); len(parts) == 2 && !strings.Contains(s, " and ") &&
+		!strings.Contains(s, "&") && len(strings.Fields(parts[0])) == 1 {
+		return []string{NormalizeAuthor(s)}
+	}
+
+	s = strings.Replace(s, " & ", ", ", -1)
+	s = strings.Replace(s, " and ", ", ", -1)
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || isNoise(name) {
+			continue
+		}
+		names = append(names, NormalizeAuthor(name))
+	}
+
+	return names
+}
+
+// isNoise reports whether a name is a placeholder rather than a person
+func isNoise(name string) bool {
+	switch strings.ToLower(name) {
+	case "the author", "the authors", "various", "others", "et al", "et al.":
+		return true
+	}
+
+	return false
+}
+
+// NormalizeAuthor tidies up an author name: "WEIR, ANDY" and
+// "  Andy   Weir " both become "Andy Weir"
+func NormalizeAuthor(name string) string {
+	name = strings.TrimSpace(name)
+	if i := strings.Index(name, ","); i >= 0 {
+		last, first := strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:])
+		// "Jr." and similar suffixes aren't first names
+		if first != "" && !isSuffix(first) {
+			name = first + " " + last
+		}
+	}
+	name = strings.Join(strings.Fields(name), " ")
+
+	// fix all caps or all lowercase names
+	if strings.ToUpper(name) == name || strings.ToLower(name) == name {
+		name = strings.Title(strings.ToLower(name))
+	}
+
+	return name
+}
+
+func isSuffix(s string) bool {
+	switch strings.ToLower(strings.TrimSuffix(s, ".")) {
+	case "jr", "sr", "ii", "iii", "iv", "md", "phd":
+		return true
+	}
+
+	return false
+}
+
+// AuthorKey identifies an author across spelling variants:
+// "J.R.R. Tolkien", "J. R. R. Tolkien" and "Tolkien, J.R.R."
+// all have the same key
+func AuthorKey(name string) string {
+	authors, _ := SplitAuthors(name)
+	if len(authors) > 0 {
+		name = authors[0]
+	}
+
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func normalizeTitle(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// AuthorStats are the statistics of a single author
+type AuthorStats struct {
+	Name string
+	// Variants are the other spellings of the name that were merged
+	Variants []string
+	// Titles are the distinct titles charted
+	Titles []string
+	// TotalWeeks is the number of weeks spent on lists, summed over
+	// titles and lists
+	TotalWeeks int
+	BestRank   int
+	Lists      []string
+	// CoContributors are the people credited alongside the author
+	CoContributors []string
+}
+
+// Authors computes per author statistics, sorted by total weeks on lists.
+// A book credited to several authors counts for each of them.
+//
+// Weeks on a list are taken as the larger of the number of entries
+// and the highest weeks on list reported, so that a single snapshot
+// still accounts for a book's earlier weeks.
+func Authors(entries []Entry) []AuthorStats {
+	type acc struct {
+		stats    AuthorStats
+		names    map[string]int
+		titles   map[string]bool
+		lists    map[string]bool
+		others   map[string]bool
+		weeks    map[string]int
+		maxWeeks map[string]int
+	}
+	byKey := make(map[string]*acc)
+
+	for _, e := range entries {
+		authors, with := SplitAuthors(e.Author)
+		credits := ParseContributor(e.Contributor)
+
+		for _, name := range authors {
+			key := AuthorKey(name)
+			a, ok := byKey[key]
+			if !ok {
+				a = &acc{
+					names:    make(map[string]int),
+					titles:   make(map[string]bool),
+					lists:    make(map[string]bool),
+					others:   make(map[string]bool),
+					weeks:    make(map[string]int),
+					maxWeeks: make(map[string]int),
+				}
+				byKey[key] = a
+			}
+			a.names[name]++
+			if !a.titles[normalizeTitle(e.Title)] {
+				a.titles[normalizeTitle(e.Title)] = true
+				a.stats.Titles = append(a.stats.Titles, e.Title)
+			}
+			a.lists[e.ListName] = true
+			if a.stats.BestRank == 0 || e.Rank < a.stats.BestRank {
+				a.stats.BestRank = e.Rank
+			}
+
+			onList := normalizeTitle(e.Title) + "|" + e.ListName
+			a.weeks[onList]++
+			if e.WeeksOnList > a.maxWeeks[onList] {
+				a.maxWeeks[onList] = e.WeeksOnList
+			}
+
+			for _, other := range authors {
+				a.others[other] = true
+			}
+			for _, other := range with {
+				a.others[other] = true
+			}
+			for _, c := range credits {
+				a.others[c.Name] = true
+			}
+			for other := range a.others {
+				if AuthorKey(other) == key {
+					delete(a.others, other)
+				}
+			}
+		}
+	}
+
+	out := make([]AuthorStats, 0, len(byKey))
+	for _, a := range byKey {
+		// the most used spelling wins, ties going to the first alphabetically
+		var names []string
+		for name := range a.names {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if a.names[names[i]] != a.names[names[j]] {
+				return a.names[names[i]] > a.names[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		a.stats.Name = names[0]
+		if len(names) > 1 {
+			a.stats.Variants = names[1:]
+		}
+
+		for onList, n := range a.weeks {
+			if a.maxWeeks[onList] > n {
+				n = a.maxWeeks[onList]
+			}
+			a.stats.TotalWeeks += n
+		}
+		a.stats.Lists = sortedKeys(a.lists)
+		a.stats.CoContributors = sortedKeys(a.others)
+		out = append(out, a.stats)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TotalWeeks != out[j].TotalWeeks {
+			return out[i].TotalWeeks > out[j].TotalWeeks
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
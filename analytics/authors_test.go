@@ -0,0 +1,89 @@
+package analytics
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func TestSplitAuthors(t *testing.T) {
+	tests := []struct {
+		in      string
+		authors []string
+		with    []string
+	}{
+		{"Andy Weir", []string{"Andy Weir"}, nil},
+		{"Weir, Andy", []string{"Andy Weir"}, nil},
+		{"Clive Cussler and Justin Scott", []string{"Clive Cussler", "Justin Scott"}, nil},
+		{"James Patterson with Maxine Paetro", []string{"James Patterson"}, []string{"Maxine Paetro"}},
+		{"Ina Garten, Jeffrey Snover & Sam Sifton", []string{"Ina Garten", "Jeffrey Snover", "Sam Sifton"}, nil},
+		{"STEPHEN KING", []string{"Stephen King"}, nil},
+	}
+	for _, tt := range tests {
+		authors, with := SplitAuthors(tt.in)
+		if !reflect.DeepEqual(authors, tt.authors) || !reflect.DeepEqual(with, tt.with) {
+			t.Errorf("SplitAuthors(%q) = %q, %q, want %q, %q", tt.in, authors, with, tt.authors, tt.with)
+		}
+	}
+}
+
+func TestParseContributor(t *testing.T) {
+	got := ParseContributor("by Mo Willems; illustrated by Tony Fucile; read by the author")
+	want := []Contributor{{Name: "Mo Willems"}, {Name: "Tony Fucile", Role: "illustrated"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestAuthorKey(t *testing.T) {
+	variants := []string{"J.R.R. Tolkien", "J. R. R. Tolkien", "Tolkien, J.R.R.", "J.R.R. TOLKIEN"}
+	want := AuthorKey(variants[0])
+	for _, v := range variants[1:] {
+		if got := AuthorKey(v); got != want {
+			t.Errorf("AuthorKey(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	snapshots := []string{
+		`{"results": {"list_name": "Hardcover Fiction", "published_date": "2016-03-13", "books": [
+			{"rank": 2, "weeks_on_list": 1, "title": "THE GANGSTER", "author": "Clive Cussler and Justin Scott", "contributor": "by Clive Cussler and Justin Scott"},
+			{"rank": 5, "weeks_on_list": 3, "title": "NEST", "author": "Clive Cussler with Grant Blackwood", "contributor": "by Clive Cussler with Grant Blackwood"}]}}`,
+		`{"results": {"list_name": "Hardcover Fiction", "published_date": "2016-03-20", "books": [
+			{"rank": 1, "weeks_on_list": 2, "title": "THE GANGSTER", "author": "Clive Cussler and Justin Scott", "contributor": "by Clive Cussler and Justin Scott"}]}}`,
+		`{"results": {"list_name": "Combined Print and E-Book Fiction", "published_date": "2016-03-20", "books": [
+			{"rank": 3, "weeks_on_list": 1, "title": "The Gangster", "author": "Cussler, Clive", "contributor": "by Clive Cussler"}]}}`,
+	}
+	var lists []*books.ListByDate
+	for _, s := range snapshots {
+		var l books.ListByDate
+		if err := json.Unmarshal([]byte(s), &l); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lists = append(lists, &l)
+	}
+
+	stats := Authors(FromLists(lists...))
+	if len(stats) != 2 {
+		t.Fatalf("got %d authors, want 2: %+v", len(stats), stats)
+	}
+
+	got := stats[0]
+	want := AuthorStats{
+		Name:           "Clive Cussler",
+		Titles:         []string{"THE GANGSTER", "NEST"},
+		TotalWeeks:     6,
+		BestRank:       1,
+		Lists:          []string{"Combined Print and E-Book Fiction", "Hardcover Fiction"},
+		CoContributors: []string{"Grant Blackwood", "Justin Scott"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if stats[1].Name != "Justin Scott" || stats[1].TotalWeeks != 2 {
+		t.Errorf("got %+v, want Justin Scott with 2 weeks", stats[1])
+	}
+}
@@ -0,0 +1,130 @@
+package analytics
+
+import (
+	"sort"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// Appearance is a title's rank on one list
+type Appearance struct {
+	ListName    string
+	DisplayName string
+	Rank        int
+	ISBN13      string
+}
+
+// CrossListTitle is a title found on several lists in the same week
+type CrossListTitle struct {
+	Title  string
+	Author string
+	// ISBN13s are the primary ISBNs of the title's formats
+	ISBN13s     []string
+	Appearances []Appearance
+}
+
+// BestRank returns the title's highest rank across lists
+func (t CrossListTitle) BestRank() int {
+	best := 0
+	for _, a := range t.Appearances {
+		if best == 0 || a.Rank < best {
+			best = a.Rank
+		}
+	}
+
+	return best
+}
+
+// CrossListOverview is CrossList over every list of an overview
+func CrossListOverview(o *books.Overview) []CrossListTitle {
+	return CrossList(FromOverviews(o))
+}
+
+// CrossList finds the titles appearing on more than one list. Entries are
+// expected to be of a single week, see Filter and Between.
+//
+// Entries are the same title when they share a primary ISBN13, or, as
+// formats have their own ISBNs, the same normalised title and author.
+// Titles are sorted by number of lists, then by best rank.
+func CrossList(entries []Entry) []CrossListTitle {
+	// union-find over entry indexes
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	byISBN := make(map[string]int)
+	byTitle := make(map[string]int)
+	for i, e := range entries {
+		if e.ISBN13 != "" {
+			if j, ok := byISBN[e.ISBN13]; ok {
+				union(i, j)
+			} else {
+				byISBN[e.ISBN13] = i
+			}
+		}
+		key := titleKey(e)
+		if j, ok := byTitle[key]; ok {
+			union(i, j)
+		} else {
+			byTitle[key] = i
+		}
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range entries {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	var titles []CrossListTitle
+	for _, root := range roots {
+		lists := make(map[string]bool)
+		isbns := make(map[string]bool)
+		t := CrossListTitle{Title: entries[root].Title, Author: entries[root].Author}
+		for _, i := range groups[root] {
+			e := entries[i]
+			lists[e.ListName] = true
+			if e.ISBN13 != "" && !isbns[e.ISBN13] {
+				isbns[e.ISBN13] = true
+				t.ISBN13s = append(t.ISBN13s, e.ISBN13)
+			}
+			t.Appearances = append(t.Appearances, Appearance{
+				ListName:    e.ListName,
+				DisplayName: e.DisplayName,
+				Rank:        e.Rank,
+				ISBN13:      e.ISBN13,
+			})
+		}
+		if len(lists) < 2 {
+			continue
+		}
+		sort.SliceStable(t.Appearances, func(i, j int) bool {
+			return t.Appearances[i].Rank < t.Appearances[j].Rank
+		})
+		titles = append(titles, t)
+	}
+
+	sort.SliceStable(titles, func(i, j int) bool {
+		if len(titles[i].Appearances) != len(titles[j].Appearances) {
+			return len(titles[i].Appearances) > len(titles[j].Appearances)
+		}
+		return titles[i].BestRank() < titles[j].BestRank()
+	})
+
+	return titles
+}
@@ -0,0 +1,49 @@
+package analytics
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func TestCrossListOverview(t *testing.T) {
+	overviewJSON := `{"results": {"published_date": "2016-03-20", "lists": [
+		{"list_name": "Combined Print and E-Book Fiction", "display_name": "Combined Print & E-Book Fiction", "books": [
+			{"rank": 1, "title": "THE GANGSTER", "author": "Clive Cussler and Justin Scott", "primary_isbn13": "9780698406421"},
+			{"rank": 2, "title": "NEST", "author": "Jill Alexander Essbaum", "primary_isbn13": "9780812987188"}]},
+		{"list_name": "Hardcover Fiction", "display_name": "Hardcover Fiction", "books": [
+			{"rank": 3, "title": "The Gangster", "author": "Clive Cussler and Justin Scott", "primary_isbn13": "9780399175954"},
+			{"rank": 1, "title": "THE MARTIAN", "author": "Andy Weir", "primary_isbn13": "9780553418026"}]},
+		{"list_name": "E-Book Fiction", "display_name": "E-Book Fiction", "books": [
+			{"rank": 4, "title": "THE GANGSTER (EBOOK)", "author": "Clive Cussler", "primary_isbn13": "9780698406421"},
+			{"rank": 5, "title": "THE MARTIAN", "author": "Andy Weir", "primary_isbn13": "9780553418026"}]}
+	]}}`
+	var o books.Overview
+	if err := json.Unmarshal([]byte(overviewJSON), &o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	titles := CrossListOverview(&o)
+	if len(titles) != 2 {
+		t.Fatalf("got %d titles, want 2: %+v", len(titles), titles)
+	}
+
+	gangster := titles[0]
+	want := []Appearance{
+		{ListName: "Combined Print and E-Book Fiction", DisplayName: "Combined Print & E-Book Fiction", Rank: 1, ISBN13: "9780698406421"},
+		{ListName: "Hardcover Fiction", DisplayName: "Hardcover Fiction", Rank: 3, ISBN13: "9780399175954"},
+		{ListName: "E-Book Fiction", DisplayName: "E-Book Fiction", Rank: 4, ISBN13: "9780698406421"},
+	}
+	if !reflect.DeepEqual(gangster.Appearances, want) {
+		t.Errorf("got appearances %+v, want %+v", gangster.Appearances, want)
+	}
+	if !reflect.DeepEqual(gangster.ISBN13s, []string{"9780698406421", "9780399175954"}) {
+		t.Errorf("got ISBNs %v", gangster.ISBN13s)
+	}
+
+	if titles[1].Title != "THE MARTIAN" || titles[1].BestRank() != 1 {
+		t.Errorf("got %+v, want THE MARTIAN ranked 1", titles[1])
+	}
+}
@@ -0,0 +1,183 @@
+// Package analytics computes statistics over best sellers list data,
+// such as per author or per publisher performance.
+//
+// Every function works on Entries, one per book per list edition, which are
+// built from archived ListByDate, Overview or ListHistory responses.
+package analytics
+
+import (
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// Entry is a single appearance of a book on a list edition
+type Entry struct {
+	ListName      string
+	DisplayName   string
+	PublishedDate time.Time
+	Rank          int
+	// RankLastWeek is 0 if the book wasn't on the previous edition
+	RankLastWeek int
+	WeeksOnList  int
+	Title        string
+	Author       string
+	Contributor  string
+	Publisher    string
+	AgeGroup     string
+	ISBN13       string
+	ISBN10       string
+}
+
+// FromLists flattens list snapshots into entries
+func FromLists(lists ...*books.ListByDate) []Entry {
+	var entries []Entry
+	for _, l := range lists {
+		res := l.Results
+		published := parseDate(res.PublishedDate)
+		for _, b := range res.Books {
+			entries = append(entries, Entry{
+				ListName:      res.ListName,
+				DisplayName:   res.DisplayName,
+				PublishedDate: published,
+				Rank:          b.Rank,
+				RankLastWeek:  b.RankLastWeek,
+				WeeksOnList:   b.WeeksOnList,
+				Title:         b.Title,
+				Author:        b.Author,
+				Contributor:   b.Contributor,
+				Publisher:     b.Publisher,
+				AgeGroup:      b.AgeGroup,
+				ISBN13:        b.PrimaryISBN13,
+				ISBN10:        b.PrimaryISBN10,
+			})
+		}
+	}
+
+	return entries
+}
+
+// FromOverviews flattens overview snapshots into entries. Overviews don't
+// carry weeks on list nor last week's rank, which are left at 0.
+func FromOverviews(overviews ...*books.Overview) []Entry {
+	var entries []Entry
+	for _, o := range overviews {
+		published := parseDate(o.Results.PublishedDate)
+		for _, l := range o.Results.Lists {
+			for _, b := range l.Books {
+				entries = append(entries, Entry{
+					ListName:      l.ListName,
+					DisplayName:   l.DisplayName,
+					PublishedDate: published,
+					Rank:          b.Rank,
+					Title:         b.Title,
+					Author:        b.Author,
+					Contributor:   b.Contributor,
+					Publisher:     b.Publisher,
+					AgeGroup:      b.AgeGroup,
+					ISBN13:        b.PrimaryISBN13,
+					ISBN10:        b.PrimaryISBN10,
+				})
+			}
+		}
+	}
+
+	return entries
+}
+
+// FromHistory flattens list history results into entries,
+// one per week in each book's rank history
+func FromHistory(histories ...*books.ListHistory) []Entry {
+	var entries []Entry
+	for _, h := range histories {
+		for _, b := range h.Results {
+			for _, r := range b.RanksHistory {
+				entries = append(entries, Entry{
+					ListName:      r.ListName,
+					DisplayName:   r.DisplayName,
+					PublishedDate: parseDate(r.PublishedDate),
+					Rank:          r.Rank,
+					RankLastWeek:  r.RanksLastWeek,
+					WeeksOnList:   r.WeeksOnList,
+					Title:         b.Title,
+					Author:        b.Author,
+					Contributor:   b.Contributor,
+					Publisher:     b.Publisher,
+					AgeGroup:      b.AgeGroup,
+					ISBN13:        r.PrimaryISBN13,
+					ISBN10:        r.PrimaryISBN10,
+				})
+			}
+		}
+	}
+
+	return entries
+}
+
+// Filter returns the entries for which every keep function returns true
+func Filter(entries []Entry, keep ...func(Entry) bool) []Entry {
+	var out []Entry
+next:
+	for _, e := range entries {
+		for _, k := range keep {
+			if !k(e) {
+				continue next
+			}
+		}
+		out = append(out, e)
+	}
+
+	return out
+}
+
+// Between keeps the entries published between from and to, inclusive.
+// A zero from or to leaves that end open.
+func Between(from, to time.Time) func(Entry) bool {
+	return func(e Entry) bool {
+		if !from.IsZero() && e.PublishedDate.Before(from) {
+			return false
+		}
+		if !to.IsZero() && e.PublishedDate.After(to) {
+			return false
+		}
+		return true
+	}
+}
+
+// OnList keeps the entries of a list, given as list name or display name
+func OnList(name string) func(Entry) bool {
+	return func(e Entry) bool {
+		return e.ListName == name || e.DisplayName == name
+	}
+}
+
+// ForAgeGroup keeps the entries of an age group, such as "Ages 8 to 12".
+// An empty group keeps the books with no age group, i.e. adult books.
+func ForAgeGroup(group string) func(Entry) bool {
+	return func(e Entry) bool {
+		return e.AgeGroup == group
+	}
+}
+
+// titleKey identifies a book across entries and formats,
+// which have different ISBNs
+func titleKey(e Entry) string {
+	return normalizeTitle(e.Title) + "|" + AuthorKey(e.Author)
+}
+
+func parseDate(s string) time.Time {
+	t, err := time.Parse(books.DateLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(books.DateLayout)
+}
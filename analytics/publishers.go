@@ -0,0 +1,203 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aliases map imprints to their parent publisher, e.g.
+// "Knopf" -> "Penguin Random House". Keys are matched case insensitively.
+type Aliases map[string]string
+
+// Resolve returns the parent publisher of an imprint. Publishers made of
+// several imprints, such as "Portfolio/Penguin", are resolved by their
+// first known imprint. Unknown publishers are returned as is.
+func (a Aliases) Resolve(publisher string) string {
+	return a.normalized().resolve(publisher)
+}
+
+// normalized returns a copy of the aliases with lowercase keys
+func (a Aliases) normalized() Aliases {
+	lower := make(Aliases, len(a))
+	for imprint, parent := range a {
+		lower[strings.ToLower(strings.TrimSpace(imprint))] = parent
+	}
+
+	return lower
+}
+
+// resolve is Resolve on normalized aliases
+func (a Aliases) resolve(publisher string) string {
+	publisher = strings.TrimSpace(publisher)
+	if parent, ok := a[strings.ToLower(publisher)]; ok {
+		return parent
+	}
+	for _, imprint := range strings.Split(publisher, "/") {
+		if parent, ok := a[strings.ToLower(strings.TrimSpace(imprint))]; ok {
+			return parent
+		}
+	}
+
+	return publisher
+}
+
+// PublisherStats are the statistics of a single publisher
+type PublisherStats struct {
+	Publisher string
+	// Imprints are the publisher names merged into Publisher
+	Imprints []string
+	// Titles is the number of distinct titles that made the list
+	Titles int
+	// Weeks is the number of weeks held, summed over titles
+	Weeks      int
+	NumberOnes int
+	// Points are the rank-weighted points: a book ranked r on an
+	// edition of n books scores n+1-r
+	Points float64
+	// Share is the part of all points scored by the publisher
+	Share float64
+}
+
+// PublisherReport is the market share of publishers over a set of entries
+type PublisherReport struct {
+	// List is the list the report covers, empty for all lists
+	List       string
+	From       time.Time
+	To         time.Time
+	Publishers []PublisherStats
+}
+
+// Publishers computes the market share of each publisher over entries,
+// merging imprints into their parents with aliases, which may be nil.
+// Use Filter to restrict the report to a date range.
+func Publishers(entries []Entry, aliases Aliases) PublisherReport {
+	type edition struct {
+		list string
+		date time.Time
+	}
+	lengths := make(map[edition]int)
+	for _, e := range entries {
+		lengths[edition{e.ListName, e.PublishedDate}]++
+	}
+
+	type acc struct {
+		stats    PublisherStats
+		imprints map[string]bool
+		titles   map[string]bool
+	}
+	byName := make(map[string]*acc)
+	aliases = aliases.normalized()
+	var report PublisherReport
+	var total float64
+
+	for _, e := range entries {
+		if report.From.IsZero() || e.PublishedDate.Before(report.From) {
+			report.From = e.PublishedDate
+		}
+		if e.PublishedDate.After(report.To) {
+			report.To = e.PublishedDate
+		}
+
+		name := aliases.resolve(e.Publisher)
+		a, ok := byName[name]
+		if !ok {
+			a = &acc{
+				stats:    PublisherStats{Publisher: name},
+				imprints: make(map[string]bool),
+				titles:   make(map[string]bool),
+			}
+			byName[name] = a
+		}
+		if imprint := strings.TrimSpace(e.Publisher); imprint != name {
+			a.imprints[imprint] = true
+		}
+		a.titles[titleKey(e)] = true
+		a.stats.Weeks++
+		if e.Rank == 1 {
+			a.stats.NumberOnes++
+		}
+
+		points := float64(lengths[edition{e.ListName, e.PublishedDate}] + 1 - e.Rank)
+		if points < 1 {
+			points = 1
+		}
+		a.stats.Points += points
+		total += points
+	}
+
+	for _, a := range byName {
+		a.stats.Titles = len(a.titles)
+		a.stats.Imprints = sortedKeys(a.imprints)
+		if len(a.stats.Imprints) == 0 {
+			a.stats.Imprints = nil
+		}
+		if total > 0 {
+			a.stats.Share = a.stats.Points / total
+		}
+		report.Publishers = append(report.Publishers, a.stats)
+	}
+	sort.Slice(report.Publishers, func(i, j int) bool {
+		p, q := report.Publishers[i], report.Publishers[j]
+		if p.Points != q.Points {
+			return p.Points > q.Points
+		}
+		return p.Publisher < q.Publisher
+	})
+
+	return report
+}
+
+// PublishersByList is Publishers computed separately for every list,
+// sorted by list name
+func PublishersByList(entries []Entry, aliases Aliases) []PublisherReport {
+	byList := make(map[string][]Entry)
+	for _, e := range entries {
+		byList[e.ListName] = append(byList[e.ListName], e)
+	}
+
+	var reports []PublisherReport
+	for list, es := range byList {
+		r := Publishers(es, aliases)
+		r.List = list
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].List < reports[j].List })
+
+	return reports
+}
+
+// WriteCSV writes the reports as CSV, one row per publisher per report
+func WriteCSV(w io.Writer, reports ...PublisherReport) error {
+	cw := csv.NewWriter(w)
+	header := []string{"list", "from", "to", "publisher", "imprints", "titles", "weeks", "number_ones", "points", "share"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		for _, p := range r.Publishers {
+			row := []string{
+				r.List,
+				formatDate(r.From),
+				formatDate(r.To),
+				p.Publisher,
+				strings.Join(p.Imprints, "; "),
+				strconv.Itoa(p.Titles),
+				strconv.Itoa(p.Weeks),
+				strconv.Itoa(p.NumberOnes),
+				strconv.FormatFloat(p.Points, 'f', -1, 64),
+				strconv.FormatFloat(p.Share, 'f', 4, 64),
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func TestAliasesResolve(t *testing.T) {
+	aliases := Aliases{"Knopf": "Penguin Random House", "putnam": "Penguin Random House"}
+
+	tests := map[string]string{
+		"Knopf":                    "Penguin Random House",
+		"KNOPF":                    "Penguin Random House",
+		"Portfolio/Penguin/Putnam": "Penguin Random House",
+		"Scribner":                 "Scribner",
+	}
+	for in, want := range tests {
+		if got := aliases.Resolve(in); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPublishers(t *testing.T) {
+	snapshots := []string{
+		`{"results": {"list_name": "Hardcover Fiction", "published_date": "2016-03-13", "books": [
+			{"rank": 1, "title": "THE GANGSTER", "publisher": "Putnam"},
+			{"rank": 2, "title": "NEST", "publisher": "Scribner"},
+			{"rank": 3, "title": "THE MARTIAN", "publisher": "Knopf"}]}}`,
+		`{"results": {"list_name": "Hardcover Fiction", "published_date": "2016-03-20", "books": [
+			{"rank": 1, "title": "THE GANGSTER", "publisher": "Putnam"},
+			{"rank": 2, "title": "THE MARTIAN", "publisher": "Knopf"},
+			{"rank": 3, "title": "NEST", "publisher": "Scribner"}]}}`,
+	}
+	var lists []*books.ListByDate
+	for _, s := range snapshots {
+		var l books.ListByDate
+		if err := json.Unmarshal([]byte(s), &l); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lists = append(lists, &l)
+	}
+
+	report := Publishers(FromLists(lists...), Aliases{"Knopf": "PRH", "Putnam": "PRH"})
+	if len(report.Publishers) != 2 {
+		t.Fatalf("got %d publishers, want 2: %+v", len(report.Publishers), report.Publishers)
+	}
+
+	prh := report.Publishers[0]
+	if prh.Publisher != "PRH" || prh.Titles != 2 || prh.Weeks != 4 || prh.NumberOnes != 2 {
+		t.Errorf("unexpected stats %+v", prh)
+	}
+	// 3+1 and 2+2 out of 12 points
+	if prh.Points != 9 || prh.Share != 0.75 {
+		t.Errorf("got %v points and %v share, want 9 and 0.75", prh.Points, prh.Share)
+	}
+	if strings.Join(prh.Imprints, ",") != "Knopf,Putnam" {
+		t.Errorf("got imprints %v", prh.Imprints)
+	}
+	if got := formatDate(report.From) + ".." + formatDate(report.To); got != "2016-03-13..2016-03-20" {
+		t.Errorf("got range %v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, PublishersByList(FromLists(lists...), nil)...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want a header and 3 publishers:\n%s", len(rows), buf.String())
+	}
+	if want := "Hardcover Fiction,2016-03-13,2016-03-20,Putnam,,1,2,2,6,0.5000"; rows[1] != want {
+		t.Errorf("got row %v, want %v", rows[1], want)
+	}
+}
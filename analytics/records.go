@@ -0,0 +1,248 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+)
+
+// TitleWeeks is the number of weeks a title spent on a list
+type TitleWeeks struct {
+	Title    string
+	Author   string
+	ListName string
+	Weeks    int
+	BestRank int
+}
+
+// Run is a streak of consecutive editions a title held a rank
+type Run struct {
+	Title    string
+	Author   string
+	ListName string
+	Start    time.Time
+	End      time.Time
+	// Length is the number of editions in the run
+	Length int
+}
+
+// Climb is the time a title took to reach #1 after entering a list
+type Climb struct {
+	Title    string
+	Author   string
+	ListName string
+	Entered  time.Time
+	Reached  time.Time
+	// Weeks is the number of weeks spent on the list before reaching #1,
+	// 0 for a title debuting at #1
+	Weeks int
+}
+
+// Jump is a title's move up a list from one edition to the next
+type Jump struct {
+	Title    string
+	Author   string
+	ListName string
+	Date     time.Time
+	From     int
+	To       int
+}
+
+// Places is the number of places gained
+func (j Jump) Places() int {
+	return j.From - j.To
+}
+
+// onList is every entry of a title on a list, oldest first
+type onList struct {
+	title    string
+	author   string
+	listName string
+	entries  []Entry
+}
+
+// byTitleAndList groups entries by title and list, keeping the first entry
+// of each edition if the same title appears several times on it
+func byTitleAndList(entries []Entry) []*onList {
+	groups := make(map[string]*onList)
+	var order []*onList
+	for _, e := range entries {
+		key := titleKey(e) + "|" + e.ListName
+		g, ok := groups[key]
+		if !ok {
+			g = &onList{title: e.Title, author: e.Author, listName: e.ListName}
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.entries = append(g.entries, e)
+	}
+
+	for _, g := range order {
+		sort.SliceStable(g.entries, func(i, j int) bool {
+			return g.entries[i].PublishedDate.Before(g.entries[j].PublishedDate)
+		})
+		deduped := g.entries[:0]
+		for _, e := range g.entries {
+			if n := len(deduped); n > 0 && deduped[n-1].PublishedDate.Equal(e.PublishedDate) {
+				continue
+			}
+			deduped = append(deduped, e)
+		}
+		g.entries = deduped
+	}
+
+	return order
+}
+
+// editions returns the index of each list's editions found in entries,
+// so that consecutive editions can be told apart from gaps
+func editions(entries []Entry) map[string]map[time.Time]int {
+	dates := make(map[string][]time.Time)
+	seen := make(map[string]map[time.Time]bool)
+	for _, e := range entries {
+		if seen[e.ListName] == nil {
+			seen[e.ListName] = make(map[time.Time]bool)
+		}
+		if !seen[e.ListName][e.PublishedDate] {
+			seen[e.ListName][e.PublishedDate] = true
+			dates[e.ListName] = append(dates[e.ListName], e.PublishedDate)
+		}
+	}
+
+	index := make(map[string]map[time.Time]int)
+	for list, ds := range dates {
+		sort.Slice(ds, func(i, j int) bool { return ds[i].Before(ds[j]) })
+		index[list] = make(map[time.Time]int, len(ds))
+		for i, d := range ds {
+			index[list][d] = i
+		}
+	}
+
+	return index
+}
+
+// LongestOnList returns the n titles that spent the most weeks on a list.
+// Weeks are the larger of the number of editions found and the highest
+// weeks on list reported.
+func LongestOnList(entries []Entry, n int) []TitleWeeks {
+	var out []TitleWeeks
+	for _, g := range byTitleAndList(entries) {
+		tw := TitleWeeks{Title: g.title, Author: g.author, ListName: g.listName, Weeks: len(g.entries)}
+		for _, e := range g.entries {
+			if e.WeeksOnList > tw.Weeks {
+				tw.Weeks = e.WeeksOnList
+			}
+			if tw.BestRank == 0 || e.Rank < tw.BestRank {
+				tw.BestRank = e.Rank
+			}
+		}
+		out = append(out, tw)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Weeks > out[j].Weeks })
+
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
+
+// LongestNumberOneRuns returns the n longest runs at #1
+// over consecutive editions of a list
+func LongestNumberOneRuns(entries []Entry, n int) []Run {
+	index := editions(entries)
+
+	var out []Run
+	for _, g := range byTitleAndList(entries) {
+		// run is the index in out of the current run, -1 if none
+		run, last := -1, -1
+		for _, e := range g.entries {
+			i := index[g.listName][e.PublishedDate]
+			if e.Rank != 1 {
+				run = -1
+				continue
+			}
+			if run >= 0 && i == last+1 {
+				out[run].End = e.PublishedDate
+				out[run].Length++
+			} else {
+				out = append(out, Run{Title: g.title, Author: g.author, ListName: g.listName,
+					Start: e.PublishedDate, End: e.PublishedDate, Length: 1})
+				run = len(out) - 1
+			}
+			last = i
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Length > out[j].Length })
+
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
+
+// FastestClimbs returns the n titles that reached #1 the fastest after
+// entering a list. The climb is measured with the weeks on list reported
+// when available, otherwise with the editions found in entries.
+func FastestClimbs(entries []Entry, n int) []Climb {
+	index := editions(entries)
+
+	var out []Climb
+	for _, g := range byTitleAndList(entries) {
+		first := g.entries[0]
+		for _, e := range g.entries {
+			if e.Rank != 1 {
+				continue
+			}
+			c := Climb{Title: g.title, Author: g.author, ListName: g.listName,
+				Entered: first.PublishedDate, Reached: e.PublishedDate}
+			if e.WeeksOnList > 0 {
+				c.Weeks = e.WeeksOnList - 1
+				c.Entered = e.PublishedDate.AddDate(0, 0, -7*c.Weeks)
+			} else {
+				c.Weeks = index[g.listName][e.PublishedDate] - index[g.listName][first.PublishedDate]
+			}
+			out = append(out, c)
+			break
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Weeks < out[j].Weeks })
+
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
+
+// BiggestJumps returns the n biggest single week moves up a list. Last
+// week's rank is taken from the entries, or from the previous edition
+// when it isn't reported.
+func BiggestJumps(entries []Entry, n int) []Jump {
+	index := editions(entries)
+
+	var out []Jump
+	for _, g := range byTitleAndList(entries) {
+		for k, e := range g.entries {
+			from := e.RankLastWeek
+			if from == 0 && k > 0 {
+				prev := g.entries[k-1]
+				if index[g.listName][prev.PublishedDate]+1 == index[g.listName][e.PublishedDate] {
+					from = prev.Rank
+				}
+			}
+			if from == 0 || from <= e.Rank {
+				continue
+			}
+			out = append(out, Jump{Title: g.title, Author: g.author, ListName: g.listName,
+				Date: e.PublishedDate, From: from, To: e.Rank})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Places() > out[j].Places() })
+
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
@@ -0,0 +1,91 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+func week(n int) time.Time {
+	return time.Date(2016, 1, 3, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 7*n)
+}
+
+// recordEntries has five consecutive hardcover fiction editions:
+//
+//	week  0    1    2    3    4
+//	A     3    1    1    2    1
+//	B     1    2    -    1    2
+//	C     -    9    2    -    -
+func recordEntries() []Entry {
+	ranks := map[string][]int{
+		"A": {3, 1, 1, 2, 1},
+		"B": {1, 2, 0, 1, 2},
+		"C": {0, 9, 2, 0, 0},
+	}
+	var entries []Entry
+	for title, rs := range ranks {
+		for w, r := range rs {
+			if r == 0 {
+				continue
+			}
+			entries = append(entries, Entry{ListName: "Hardcover Fiction", PublishedDate: week(w),
+				Title: title, Author: "Author " + title, Rank: r})
+		}
+	}
+	// an older book, already on the list for a long time, on another list
+	entries = append(entries, Entry{ListName: "Paperback Nonfiction", PublishedDate: week(0),
+		Title: "D", Author: "Author D", Rank: 1, WeeksOnList: 120, AgeGroup: "Ages 8 to 12"})
+
+	return entries
+}
+
+func TestLongestOnList(t *testing.T) {
+	got := LongestOnList(recordEntries(), 2)
+	if len(got) != 2 {
+		t.Fatalf("got %d titles, want 2", len(got))
+	}
+	if got[0].Title != "D" || got[0].Weeks != 120 || got[1].Title != "A" || got[1].Weeks != 5 {
+		t.Errorf("got %+v", got)
+	}
+
+	got = LongestOnList(Filter(recordEntries(), OnList("Hardcover Fiction"), Between(week(2), week(4))), 1)
+	if got[0].Title != "A" || got[0].Weeks != 3 || got[0].BestRank != 1 {
+		t.Errorf("got %+v, want A with 3 weeks", got[0])
+	}
+}
+
+func TestLongestNumberOneRuns(t *testing.T) {
+	got := LongestNumberOneRuns(Filter(recordEntries(), OnList("Hardcover Fiction")), 0)
+	if len(got) != 4 {
+		t.Fatalf("got %d runs, want 4: %+v", len(got), got)
+	}
+	if got[0].Title != "A" || got[0].Length != 2 || !got[0].Start.Equal(week(1)) || !got[0].End.Equal(week(2)) {
+		t.Errorf("got %+v, want A for weeks 1 and 2", got[0])
+	}
+}
+
+func TestFastestClimbs(t *testing.T) {
+	got := FastestClimbs(Filter(recordEntries(), ForAgeGroup("")), 0)
+	if len(got) != 2 {
+		t.Fatalf("got %d climbs, want 2: %+v", len(got), got)
+	}
+	if got[0].Title != "B" || got[0].Weeks != 0 || got[1].Title != "A" || got[1].Weeks != 1 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestBiggestJumps(t *testing.T) {
+	got := BiggestJumps(recordEntries(), 1)
+	if len(got) != 1 {
+		t.Fatalf("got %d jumps, want 1", len(got))
+	}
+	if got[0].Title != "C" || got[0].From != 9 || got[0].To != 2 || got[0].Places() != 7 {
+		t.Errorf("got %+v, want C from 9 to 2", got[0])
+	}
+
+	// B is off the list in week 2, so week 3 isn't a jump from week 1
+	for _, j := range BiggestJumps(recordEntries(), 0) {
+		if j.Title == "B" {
+			t.Errorf("unexpected jump %+v", j)
+		}
+	}
+}
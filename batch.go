@@ -0,0 +1,144 @@
+package books
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchRequest identifies a single list edition to fetch
+type BatchRequest struct {
+	Date     string
+	ListName string
+}
+
+// BatchResult is the outcome of a single BatchRequest
+type BatchResult struct {
+	Request BatchRequest
+	List    *ListByDate
+	Err     error
+}
+
+// BatchOptions configures BatchGet
+type BatchOptions struct {
+	// Workers is the number of requests in flight at once, 4 if zero
+	Workers int
+	// Interval is the minimum time between two requests,
+	// no limit if zero
+	Interval time.Duration
+	// FailFast stops issuing requests after the first error.
+	// Requests that were not issued fail with the context's error.
+	FailFast bool
+}
+
+// BatchError is returned by BatchGet when some of the requests failed
+type BatchError struct {
+	Failed int
+	Total  int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("books: %d of %d batch requests failed", e.Failed, e.Total)
+}
+
+// limiter spaces out calls to wait by at least an interval
+type limiter struct {
+	ticker *time.Ticker
+}
+
+func newLimiter(interval time.Duration) *limiter {
+	if interval <= 0 {
+		return &limiter{}
+	}
+
+	return &limiter{ticker: time.NewTicker(interval)}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.ticker == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+func (l *limiter) stop() {
+	if l.ticker != nil {
+		l.ticker.Stop()
+	}
+}
+
+// BatchGet fetches many list editions concurrently through the Client's Doer,
+// which must be safe for concurrent use. Identical requests are only fetched
+// once and share the same *ListByDate.
+//
+// Results are returned in the order of reqs, each carrying its own error.
+// If any request failed, a *BatchError is returned along with the results.
+func (c *Client) BatchGet(ctx context.Context, reqs []BatchRequest, opts BatchOptions) ([]BatchResult, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	// unique requests, and the input positions waiting on each
+	var unique []BatchRequest
+	positions := make(map[BatchRequest][]int)
+	for i, r := range reqs {
+		if _, ok := positions[r]; !ok {
+			unique = append(unique, r)
+		}
+		positions[r] = append(positions[r], i)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lim := newLimiter(opts.Interval)
+	defer lim.stop()
+
+	results := make([]BatchResult, len(reqs))
+	jobs := make(chan BatchRequest)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				var list *ListByDate
+				err := lim.wait(ctx)
+				if err == nil {
+					list, err = c.getListByDate(ctx, r.Date, r.ListName, nil)
+				}
+				if err != nil && opts.FailFast {
+					cancel()
+				}
+				// each position is only written by the worker owning its request
+				for _, pos := range positions[r] {
+					results[pos] = BatchResult{Request: r, List: list, Err: err}
+				}
+			}
+		}()
+	}
+
+	for _, r := range unique {
+		jobs <- r
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, &BatchError{Failed: failed, Total: len(reqs)}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,94 @@
+package books
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBatchGet(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			calls[r.URL.Path]++
+			mu.Unlock()
+			if strings.Contains(r.URL.Path, "broken") {
+				return nil, errors.New("connection reset")
+			}
+			// echo the date back as the published date
+			date := strings.Split(r.URL.Path, "/")[5]
+			jsonData := fmt.Sprintf(`{"status": "OK", "results": {"published_date": %q}}`, date)
+			body := ioutil.NopCloser(bytes.NewReader([]byte(jsonData)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+
+	reqs := []BatchRequest{
+		{"2021-07-04", "hardcover-fiction"},
+		{"2021-06-27", "hardcover-fiction"},
+		{"2021-07-04", "broken"},
+		{"2021-07-04", "hardcover-fiction"},
+	}
+	results, err := c.BatchGet(context.Background(), reqs, BatchOptions{Workers: 2})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Failed != 1 || batchErr.Total != 4 {
+		t.Fatalf("got error %v, want 1 of 4 failed", err)
+	}
+	if len(results) != len(reqs) {
+		t.Fatalf("got %d results, want %d", len(results), len(reqs))
+	}
+	for i, res := range results {
+		if res.Request != reqs[i] {
+			t.Errorf("result %d: got request %v, want %v", i, res.Request, reqs[i])
+		}
+		if reqs[i].ListName == "broken" {
+			if res.Err == nil {
+				t.Errorf("result %d: expected an error", i)
+			}
+			continue
+		}
+		if res.Err != nil {
+			t.Errorf("result %d: unexpected error: %v", i, res.Err)
+			continue
+		}
+		if got := res.List.Results.PublishedDate; got != reqs[i].Date {
+			t.Errorf("result %d: got date %v, want %v", i, got, reqs[i].Date)
+		}
+	}
+
+	if got := calls["/svc/books/v3/lists/2021-07-04/hardcover-fiction.json"]; got != 1 {
+		t.Errorf("duplicate request fetched %d times, want 1", got)
+	}
+}
+
+func TestBatchGetFailFast(t *testing.T) {
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("quota exceeded")
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+
+	var reqs []BatchRequest
+	for i := 1; i <= 20; i++ {
+		reqs = append(reqs, BatchRequest{fmt.Sprintf("2021-01-%02d", i), "hardcover-fiction"})
+	}
+	results, err := c.BatchGet(context.Background(), reqs, BatchOptions{Workers: 1, FailFast: true})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !errors.Is(results[len(results)-1].Err, context.Canceled) {
+		t.Errorf("got %v for the last request, want %v", results[len(results)-1].Err, context.Canceled)
+	}
+}
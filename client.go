@@ -1,11 +1,16 @@
 package books
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"time"
+
+	"github.com/eddogola/nytimesbooks/internal/singleflight"
 )
 
 // Doer interface defines the Do function
@@ -18,6 +23,17 @@ type Client struct {
 	base       string
 	apiKey     string
 	HTTPClient Doer
+
+	coalesce bool
+	flight   singleflight.Group
+	maxSize  int64
+	strict   bool
+	observer Observer
+
+	statusErrors bool
+
+	logger    *slog.Logger
+	logLevels LogLevels
 }
 
 // OptionFunc defines the function used to alter client in the constructor
@@ -30,6 +46,7 @@ func NewClient(apiKey string, options ...OptionFunc) *Client {
 		base:       "https://api.nytimes.com/svc/books/v3",
 		apiKey:     apiKey,
 		HTTPClient: http.DefaultClient,
+		logLevels:  DefaultLogLevels,
 	}
 
 	for _, option := range options {
@@ -47,16 +64,115 @@ func WithHTTPClient(doer Doer) OptionFunc {
 	}
 }
 
+// WithRequestCoalescing makes concurrent identical GETs share a single
+// call to the API. Each caller gets its own copy of the response body,
+// and a caller giving up on its context doesn't cancel the shared call.
+// The streaming methods aren't coalesced, so that they still stream.
+func WithRequestCoalescing() OptionFunc {
+	return func(c *Client) {
+		c.coalesce = true
+	}
+}
+
+// WithMaxResponseSize limits the size of response bodies to n bytes.
+// Reading past the limit fails with ErrResponseTooLarge.
+func WithMaxResponseSize(n int64) OptionFunc {
+	return func(c *Client) {
+		c.maxSize = n
+	}
+}
+
 func (c *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	if !c.coalesce {
+		return c.do(ctx, url)
+	}
+
+	ch := c.flight.DoChan(coalesceKey(url), func() (interface{}, error) {
+		// the shared call must outlive any single waiter
+		resp, err := c.do(withEndpoint(context.Background(), endpointFrom(ctx)), url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return &sharedResponse{resp: resp, body: body}, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*sharedResponse).copy(), nil
+	}
+}
+
+// sharedResponse is a response read in full, handed out to every waiter
+type sharedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+func (sr *sharedResponse) copy() *http.Response {
+	resp := *sr.resp
+	resp.Header = sr.resp.Header.Clone()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(sr.body))
+
+	return &resp
+}
+
+// coalesceKey normalises a request URL, leaving out the api key
+func coalesceKey(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	q := u.Query()
+	q.Del("api-key")
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func (c *Client) do(ctx context.Context, url string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	var obs *observation
+	if c.observer != nil {
+		req, obs = c.startObservation(req)
+	}
+
+	start := time.Now()
 	resp, err := c.HTTPClient.Do(req)
+	if c.logger != nil {
+		c.logResponse(ctx, url, resp, err, time.Since(start))
+	}
 	if err != nil {
+		if obs != nil {
+			obs.fail(err)
+		}
 		return nil, err
 	}
+	if c.maxSize > 0 {
+		resp.Body = &limitedBody{ReadCloser: resp.Body, n: c.maxSize, limit: c.maxSize}
+	}
+	if obs != nil {
+		obs.wrap(resp)
+	}
+	if c.statusErrors && resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
 
 	return resp, err
 }
@@ -97,14 +213,15 @@ func (c *Client) GetBestSellersList(qp QueryParam) (*List, error) {
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.get(context.Background(), URL)
+	ctx := withEndpoint(context.Background(), ListsEndpoint)
+	resp, err := c.get(ctx, URL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var list List
-	err = json.NewDecoder(resp.Body).Decode(&list)
+	err = c.decode(ctx, resp.Body, &list)
 	if err != nil {
 		return nil, err
 	}
@@ -114,19 +231,24 @@ func (c *Client) GetBestSellersList(qp QueryParam) (*List, error) {
 
 // GetBestSellersListByDate Gets Best Sellers list by date.
 func (c *Client) GetBestSellersListByDate(date, listName string, qp QueryParam) (*ListByDate, error) {
+	return c.getListByDate(context.Background(), date, listName, qp)
+}
+
+func (c *Client) getListByDate(ctx context.Context, date, listName string, qp QueryParam) (*ListByDate, error) {
 	endpoint := fmt.Sprintf(ListsByDateEndpoint, date, listName)
 	URL, err := c.makeLink(endpoint, qp)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.get(context.Background(), URL)
+	ctx = withEndpoint(ctx, ListsByDateEndpoint)
+	resp, err := c.get(ctx, URL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var list ListByDate
-	err = json.NewDecoder(resp.Body).Decode(&list)
+	err = c.decode(ctx, resp.Body, &list)
 	if err != nil {
 		return nil, err
 	}
@@ -140,14 +262,15 @@ func (c *Client) GetBestSellersListHistory(qp QueryParam) (*ListHistory, error)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.get(context.Background(), URL)
+	ctx := withEndpoint(context.Background(), HistoryEndpoint)
+	resp, err := c.get(ctx, URL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var hist ListHistory
-	err = json.NewDecoder(resp.Body).Decode(&hist)
+	err = c.decode(ctx, resp.Body, &hist)
 	if err != nil {
 		return nil, err
 	}
@@ -161,14 +284,15 @@ func (c *Client) GetBestSellersListNames() (*Names, error) {
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.get(context.Background(), URL)
+	ctx := withEndpoint(context.Background(), NamesEndpoint)
+	resp, err := c.get(ctx, URL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var names Names
-	err = json.NewDecoder(resp.Body).Decode(&names)
+	err = c.decode(ctx, resp.Body, &names)
 	if err != nil {
 		return nil, err
 	}
@@ -182,14 +306,15 @@ func (c *Client) GetOverview(qp QueryParam) (*Overview, error) {
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.get(context.Background(), URL)
+	ctx := withEndpoint(context.Background(), OverviewEndpoint)
+	resp, err := c.get(ctx, URL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var overview Overview
-	err = json.NewDecoder(resp.Body).Decode(&overview)
+	err = c.decode(ctx, resp.Body, &overview)
 	if err != nil {
 		return nil, err
 	}
@@ -199,18 +324,38 @@ func (c *Client) GetOverview(qp QueryParam) (*Overview, error) {
 
 // GetReviews Gets book reviews.
 func (c *Client) GetReviews(qp QueryParam) (*Reviews, error) {
+	return c.getReviews(context.Background(), qp)
+}
+
+// GetReviewsByISBN Gets book reviews by ISBN, either 10 or 13 digits.
+func (c *Client) GetReviewsByISBN(isbn string) (*Reviews, error) {
+	return c.getReviews(context.Background(), QueryParam{"isbn": isbn})
+}
+
+// GetReviewsByTitle Gets book reviews by book title.
+func (c *Client) GetReviewsByTitle(title string) (*Reviews, error) {
+	return c.getReviews(context.Background(), QueryParam{"title": title})
+}
+
+// GetReviewsByAuthor Gets book reviews by author, as first and last name.
+func (c *Client) GetReviewsByAuthor(author string) (*Reviews, error) {
+	return c.getReviews(context.Background(), QueryParam{"author": author})
+}
+
+func (c *Client) getReviews(ctx context.Context, qp QueryParam) (*Reviews, error) {
 	URL, err := c.makeLink(ReviewsEndpoint, qp)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.get(context.Background(), URL)
+	ctx = withEndpoint(ctx, ReviewsEndpoint)
+	resp, err := c.get(ctx, URL)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var reviews Reviews
-	err = json.NewDecoder(resp.Body).Decode(&reviews)
+	err = c.decode(ctx, resp.Body, &reviews)
 	if err != nil {
 		return nil, err
 	}
@@ -8,6 +8,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -293,4 +297,100 @@ func TestGetReviews(t *testing.T) {
 	if !reflect.DeepEqual(got, &want) {
 		t.Errorf("got %v want %v", got, want)
 	}
-}
\ No newline at end of file
+}
+
+func TestHostRewriter(t *testing.T) {
+	var gotURL string
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			gotURL = r.URL.String()
+			body := ioutil.NopCloser(bytes.NewReader([]byte(`{}`)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	hr, err := NewHostRewriter("http://localhost:8080", mc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := NewClient("", WithHTTPClient(hr))
+	if _, err := c.GetBestSellersListNames(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	want := "http://localhost:8080/svc/books/v3/lists/names.json?api-key="
+	if gotURL != want {
+		t.Errorf("got %v, want %v", gotURL, want)
+	}
+}
+
+func TestRequestCoalescing(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			body := ioutil.NopCloser(bytes.NewReader([]byte(`{"status": "OK", "num_results": 1}`)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc), WithRequestCoalescing())
+
+	// a waiter giving up must not affect the others
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := c.get(ctx, c.base+OverviewEndpoint+"?api-key=apikey")
+		cancelled <- err
+	}()
+
+	const n = 10
+	got := make([]*Overview, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			o, err := c.GetOverview(nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			got[i] = o
+		}(i)
+	}
+
+	// hold the call until every waiter has joined it
+	key := coalesceKey(c.base + OverviewEndpoint + "?api-key=apikey")
+	for c.flight.Waiting(key) < n+1 {
+		runtime.Gosched()
+	}
+	cancel()
+	if err := <-cancelled; err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	close(release)
+	wg.Wait()
+
+	if count := atomic.LoadInt32(&calls); count != 1 {
+		t.Errorf("got %d calls, want 1", count)
+	}
+	for i := 1; i < n; i++ {
+		if got[i] == nil || got[i] == got[0] || got[i].NumResults != 1 {
+			t.Errorf("waiter %d: got %+v, want an independent copy", i, got[i])
+		}
+	}
+}
+
+func TestCoalesceKey(t *testing.T) {
+	a := coalesceKey("https://api.nytimes.com/svc/books/v3/lists.json?api-key=one&offset=20&list=hardcover-fiction")
+	b := coalesceKey("https://api.nytimes.com/svc/books/v3/lists.json?list=hardcover-fiction&api-key=two&offset=20")
+	if a != b {
+		t.Errorf("got different keys %v and %v", a, b)
+	}
+	if strings.Contains(a, "api-key") {
+		t.Errorf("key %v contains the api key", a)
+	}
+}
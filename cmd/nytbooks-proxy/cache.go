@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxEntries is the most responses the cache keeps. Keys carry the
+// client's query string, so past it the entry closest to expiring is
+// dropped to make room.
+const maxEntries = 10000
+
+// entry is a cached upstream response
+type entry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// cache is a TTL cache of upstream responses keyed by normalised request
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	now     func() time.Time
+	max     int
+}
+
+func newCache() *cache {
+	return &cache{
+		entries: make(map[string]*entry),
+		now:     time.Now,
+		max:     maxEntries,
+	}
+}
+
+func (c *cache) get(key string) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return e, true
+}
+
+func (c *cache) set(key string, e *entry, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	e.expires = now.Add(ttl)
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.max {
+		for k, old := range c.entries {
+			if now.After(old.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	for len(c.entries) >= c.max {
+		if _, ok := c.entries[key]; ok {
+			break
+		}
+		oldest := ""
+		for k, old := range c.entries {
+			if oldest == "" || old.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = e
+}
+
+// len returns the number of entries, expired or not
+func (c *cache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// sweep drops expired entries
+func (c *cache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for key, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
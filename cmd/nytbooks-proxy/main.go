@@ -0,0 +1,50 @@
+// Command nytbooks-proxy serves the Books API paths from a shared cache,
+// so that many services can use the API through a single api key.
+//
+// Clients reach it by wrapping their Doer in a books.HostRewriter:
+//
+//	hr, err := books.NewHostRewriter("http://nytbooks-proxy:8080", nil)
+//	c := books.NewClient("", books.WithHTTPClient(hr))
+//
+// The api key is read from the NYT_API_KEY environment variable.
+// Cache and quota statistics are served as JSON on /stats.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	upstream := flag.String("upstream", "https://api.nytimes.com/svc/books/v3", "Books API base URL")
+	timeout := flag.Duration("timeout", 30*time.Second, "upstream request timeout")
+	sweep := flag.Duration("sweep", 10*time.Minute, "interval between expired cache entry sweeps")
+	ttls := DefaultTTLs
+	flag.DurationVar(&ttls.Lists, "ttl-lists", ttls.Lists, "cache TTL of the lists endpoint")
+	flag.DurationVar(&ttls.ListsByDate, "ttl-lists-by-date", ttls.ListsByDate, "cache TTL of current lists by date")
+	flag.DurationVar(&ttls.PastEdition, "ttl-past-edition", ttls.PastEdition, "cache TTL of lists for a fixed date")
+	flag.DurationVar(&ttls.History, "ttl-history", ttls.History, "cache TTL of the history endpoint")
+	flag.DurationVar(&ttls.Names, "ttl-names", ttls.Names, "cache TTL of the names endpoint")
+	flag.DurationVar(&ttls.Overview, "ttl-overview", ttls.Overview, "cache TTL of the overview endpoint")
+	flag.DurationVar(&ttls.Reviews, "ttl-reviews", ttls.Reviews, "cache TTL of the reviews endpoint")
+	flag.Parse()
+
+	apiKey := os.Getenv("NYT_API_KEY")
+	if apiKey == "" {
+		log.Fatal("NYT_API_KEY is not set")
+	}
+
+	p := NewProxy(*upstream, apiKey, &http.Client{Timeout: *timeout}, ttls)
+	go func() {
+		for range time.Tick(*sweep) {
+			p.cache.sweep()
+		}
+	}()
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, p))
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/internal/singleflight"
+)
+
+// pathPrefix is the path of the Books API on api.nytimes.com.
+// Clients using a books.HostRewriter send it along, so it is stripped.
+const pathPrefix = "/svc/books/v3"
+
+// route matches an endpoint from endpoints.go and holds its cache TTL
+type route struct {
+	name    string
+	pattern *regexp.Regexp
+	ttl     func(path string) time.Duration
+}
+
+func fixed(d time.Duration) func(string) time.Duration {
+	return func(string) time.Duration { return d }
+}
+
+// endpointPattern turns an endpoint with fmt placeholders into a regexp
+func endpointPattern(endpoint string) *regexp.Regexp {
+	parts := strings.Split(endpoint, "%v")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+
+	return regexp.MustCompile("^" + strings.Join(parts, "([^/]+)") + "$")
+}
+
+// TTLs are the cache lifetimes of each endpoint
+type TTLs struct {
+	Lists       time.Duration
+	ListsByDate time.Duration
+	// PastEdition is used for ListsByDate requests for a fixed date,
+	// which don't change once published
+	PastEdition time.Duration
+	History     time.Duration
+	Names       time.Duration
+	Overview    time.Duration
+	Reviews     time.Duration
+}
+
+// DefaultTTLs are the TTLs used when none are configured
+var DefaultTTLs = TTLs{
+	Lists:       time.Hour,
+	ListsByDate: time.Hour,
+	PastEdition: 7 * 24 * time.Hour,
+	History:     6 * time.Hour,
+	Names:       24 * time.Hour,
+	Overview:    time.Hour,
+	Reviews:     24 * time.Hour,
+}
+
+func routes(ttls TTLs) []route {
+	byDate := endpointPattern(books.ListsByDateEndpoint)
+
+	// the more specific endpoints go first as ListsByDateEndpoint
+	// also matches the names, history and overview paths
+	return []route{
+		{"names", endpointPattern(books.NamesEndpoint), fixed(ttls.Names)},
+		{"history", endpointPattern(books.HistoryEndpoint), fixed(ttls.History)},
+		{"overview", endpointPattern(books.OverviewEndpoint), fixed(ttls.Overview)},
+		{"full_overview", endpointPattern(books.FullOverviewEndpoint), fixed(ttls.Overview)},
+		{"lists", endpointPattern(books.ListsEndpoint), fixed(ttls.Lists)},
+		{"reviews", endpointPattern(books.ReviewsEndpoint), fixed(ttls.Reviews)},
+		{"lists_by_date", byDate, func(path string) time.Duration {
+			m := byDate.FindStringSubmatch(path)
+			if m != nil && m[1] != "current" {
+				return ttls.PastEdition
+			}
+			return ttls.ListsByDate
+		}},
+	}
+}
+
+// Stats are the counters published on /stats
+type Stats struct {
+	Hits          int64             `json:"hits"`
+	Misses        int64             `json:"misses"`
+	Coalesced     int64             `json:"coalesced"`
+	Upstream      int64             `json:"upstream_requests"`
+	UpstreamError int64             `json:"upstream_errors"`
+	Entries       int               `json:"cache_entries"`
+	Endpoints     map[string]int64  `json:"endpoints"`
+	Quota         map[string]string `json:"quota"`
+}
+
+// Proxy is an http.Handler serving the Books API paths from a cache,
+// fetching from upstream with a single server-side api key
+type Proxy struct {
+	upstream string
+	apiKey   string
+	doer     books.Doer
+	routes   []route
+	cache    *cache
+	group    singleflight.Group
+
+	hits, misses, coalesced, requests, errors int64
+
+	mu        sync.Mutex
+	endpoints map[string]int64
+	quota     map[string]string
+}
+
+// NewProxy constructs a Proxy forwarding to upstream, e.g.
+// https://api.nytimes.com/svc/books/v3
+func NewProxy(upstream, apiKey string, doer books.Doer, ttls TTLs) *Proxy {
+	return &Proxy{
+		upstream:  strings.TrimSuffix(upstream, "/"),
+		apiKey:    apiKey,
+		doer:      doer,
+		routes:    routes(ttls),
+		cache:     newCache(),
+		endpoints: make(map[string]int64),
+		quota:     make(map[string]string),
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, pathPrefix)
+	if path == "/stats" {
+		p.serveStats(w)
+		return
+	}
+
+	rt, ok := p.match(path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	p.mu.Lock()
+	p.endpoints[rt.name]++
+	p.mu.Unlock()
+
+	// the caller's api key, if any, is dropped from both
+	// the cache key and the upstream request
+	q := r.URL.Query()
+	q.Del("api-key")
+	key := path + "?" + q.Encode()
+
+	if e, ok := p.cache.get(key); ok {
+		atomic.AddInt64(&p.hits, 1)
+		writeEntry(w, e, "HIT")
+		return
+	}
+	atomic.AddInt64(&p.misses, 1)
+
+	v, err, shared := p.group.Do(key, func() (interface{}, error) {
+		return p.fetch(path, q.Encode(), rt, key)
+	})
+	if shared {
+		atomic.AddInt64(&p.coalesced, 1)
+	}
+	if err != nil {
+		atomic.AddInt64(&p.errors, 1)
+		// upstream errors can carry the upstream URL, and so the api key
+		log.Printf("nytbooks-proxy: %s: %s", key, p.redact(err))
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		return
+	}
+
+	writeEntry(w, v.(*entry), "MISS")
+}
+
+// redact returns the text of err without the api key
+func (p *Proxy) redact(err error) string {
+	if p.apiKey == "" {
+		return err.Error()
+	}
+
+	return strings.Replace(err.Error(), p.apiKey, "REDACTED", -1)
+}
+
+func (p *Proxy) match(path string) (route, bool) {
+	for _, rt := range p.routes {
+		if rt.pattern.MatchString(path) {
+			return rt, true
+		}
+	}
+
+	return route{}, false
+}
+
+func (p *Proxy) fetch(path, query string, rt route, key string) (*entry, error) {
+	link := p.upstream + path + "?api-key=" + p.apiKey
+	if query != "" {
+		link += "&" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&p.requests, 1)
+	resp, err := p.doer.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upstream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("upstream: %v", err)
+	}
+
+	p.recordQuota(resp.Header)
+
+	e := &entry{
+		status: resp.StatusCode,
+		header: http.Header{"Content-Type": resp.Header["Content-Type"]},
+		body:   body,
+	}
+	if resp.StatusCode == http.StatusOK {
+		p.cache.set(key, e, rt.ttl(path))
+	}
+
+	return e, nil
+}
+
+// recordQuota keeps the rate limit headers of the latest upstream response
+func (p *Proxy) recordQuota(h http.Header) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name := range h {
+		if strings.HasPrefix(strings.ToLower(name), "x-ratelimit") {
+			p.quota[name] = h.Get(name)
+		}
+	}
+}
+
+// Stats returns a snapshot of the proxy counters
+func (p *Proxy) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := Stats{
+		Hits:          atomic.LoadInt64(&p.hits),
+		Misses:        atomic.LoadInt64(&p.misses),
+		Coalesced:     atomic.LoadInt64(&p.coalesced),
+		Upstream:      atomic.LoadInt64(&p.requests),
+		UpstreamError: atomic.LoadInt64(&p.errors),
+		Entries:       p.cache.len(),
+		Endpoints:     make(map[string]int64, len(p.endpoints)),
+		Quota:         make(map[string]string, len(p.quota)),
+	}
+	for k, v := range p.endpoints {
+		s.Endpoints[k] = v
+	}
+	for k, v := range p.quota {
+		s.Quota[k] = v
+	}
+
+	return s
+}
+
+func (p *Proxy) serveStats(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p.Stats())
+}
+
+func writeEntry(w http.ResponseWriter, e *entry, cacheStatus string) {
+	for k, v := range e.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("X-Cache", cacheStatus)
+	w.WriteHeader(e.status)
+	w.Write(e.body)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// mock upstream http client
+type MockClient struct {
+	MockDo func(*http.Request) (*http.Response, error)
+}
+
+func (mc *MockClient) Do(req *http.Request) (*http.Response, error) {
+	return mc.MockDo(req)
+}
+
+func TestRoutes(t *testing.T) {
+	p := NewProxy("https://api.nytimes.com/svc/books/v3", "key", nil, DefaultTTLs)
+
+	tests := []struct {
+		path string
+		name string
+		ttl  time.Duration
+	}{
+		{"/lists.json", "lists", DefaultTTLs.Lists},
+		{"/lists/names.json", "names", DefaultTTLs.Names},
+		{"/lists/overview.json", "overview", DefaultTTLs.Overview},
+		{"/lists/full-overview.json", "full_overview", DefaultTTLs.Overview},
+		{"/lists/best-sellers/history.json", "history", DefaultTTLs.History},
+		{"/lists/current/hardcover-fiction.json", "lists_by_date", DefaultTTLs.ListsByDate},
+		{"/lists/2015-07-04/hardcover-fiction.json", "lists_by_date", DefaultTTLs.PastEdition},
+		{"/reviews.json", "reviews", DefaultTTLs.Reviews},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rt, ok := p.match(tt.path)
+			if !ok {
+				t.Fatalf("no route for %v", tt.path)
+			}
+			if rt.name != tt.name {
+				t.Errorf("got route %v, want %v", rt.name, tt.name)
+			}
+			if got := rt.ttl(tt.path); got != tt.ttl {
+				t.Errorf("got ttl %v, want %v", got, tt.ttl)
+			}
+		})
+	}
+
+	if _, ok := p.match("/articlesearch.json"); ok {
+		t.Errorf("unknown path should not match")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			if got := r.URL.Query().Get("api-key"); got != "server-key" {
+				t.Errorf("got upstream api key %q, want server-key", got)
+			}
+			header := http.Header{
+				"Content-Type":                {"application/json"},
+				"X-Ratelimit-Remaining-Day": {"499"},
+			}
+			body := ioutil.NopCloser(bytes.NewReader([]byte(`{"status": "OK"}`)))
+
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: body}, nil
+		},
+	}
+	p := NewProxy("https://api.nytimes.com/svc/books/v3", "server-key", mc, DefaultTTLs)
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	hr, err := books.NewHostRewriter(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := books.NewClient("client-key", books.WithHTTPClient(hr))
+
+	// concurrent identical requests are served by one upstream call
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o, err := c.GetOverview(nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if o.Status != "OK" {
+				t.Errorf("got status %v, want OK", o.Status)
+			}
+		}()
+	}
+	// wait for all of them to share the upstream call,
+	// rather than some of them hitting the cache after it
+	for p.group.Waiting("/lists/overview.json?") < 5 {
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+
+	// later requests are served from the cache
+	if _, err := c.GetOverview(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("got %d upstream calls, want 1", got)
+	}
+
+	resp, err := http.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var stats Stats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Hits != 1 || stats.Upstream != 1 || stats.Endpoints["overview"] != 6 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if stats.Quota["X-Ratelimit-Remaining-Day"] != "499" {
+		t.Errorf("got quota %v", stats.Quota)
+	}
+}
+
+func TestProxyNotFound(t *testing.T) {
+	p := NewProxy("https://api.nytimes.com/svc/books/v3", "key", nil, DefaultTTLs)
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/svc/books/v3/nope.json", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestProxyUpstreamErrorHidesKey(t *testing.T) {
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			return nil, &url.Error{Op: "Get", URL: r.URL.String(), Err: errors.New("connection refused")}
+		},
+	}
+	p := NewProxy("https://api.nytimes.com/svc/books/v3", "SECRETKEY", mc, DefaultTTLs)
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/svc/books/v3/lists/names.json", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusBadGateway)
+	}
+	if strings.Contains(rec.Body.String(), "SECRETKEY") || strings.Contains(rec.Body.String(), "api-key") {
+		t.Errorf("response leaks the upstream URL: %s", rec.Body)
+	}
+	if strings.Contains(logged.String(), "SECRETKEY") || !strings.Contains(logged.String(), "connection refused") {
+		t.Errorf("got log %q, want the error without the key", logged.String())
+	}
+}
+
+func TestCacheMaxEntries(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newCache()
+	c.now = func() time.Time { return now }
+	c.max = 3
+
+	c.set("expired", &entry{}, time.Second)
+	c.set("soon", &entry{}, time.Hour)
+	c.set("later", &entry{}, 2*time.Hour)
+	now = now.Add(time.Minute)
+	c.set("new", &entry{}, time.Hour)
+	if _, ok := c.get("soon"); !ok || c.len() != 3 {
+		t.Errorf("got %d entries, want the expired one dropped", c.len())
+	}
+
+	c.set("newer", &entry{}, 3*time.Hour)
+	if _, ok := c.get("soon"); ok {
+		t.Error("the entry closest to expiring is still cached")
+	}
+	if c.len() != 3 {
+		t.Errorf("got %d entries, want 3", c.len())
+	}
+
+	// replacing an entry doesn't evict another
+	c.set("newer", &entry{}, time.Hour)
+	if _, ok := c.get("later"); !ok || c.len() != 3 {
+		t.Errorf("got %d entries after a replace, want 3", c.len())
+	}
+}
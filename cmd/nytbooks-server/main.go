@@ -0,0 +1,42 @@
+// Command nytbooks-server serves the Books API as a stable, versioned
+// JSON API of its own. Responses use normalised types rather than the
+// API's payloads: dates are dates, authors are split and cleaned up,
+// and published corrections are applied to lists.
+//
+// Every response is either a data envelope, {"data": ...}, or an error
+// envelope, {"error": {"status": 404, "code": "unknown_list", ...}}.
+// The routes are documented by the OpenAPI document on /v1/openapi.json.
+//
+// The api key is read from the NYT_API_KEY environment variable.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	timeout := flag.Duration("timeout", 30*time.Second, "Books API request timeout")
+	flag.Parse()
+
+	apiKey := os.Getenv("NYT_API_KEY")
+	if apiKey == "" {
+		log.Fatal("NYT_API_KEY is not set")
+	}
+
+	c := books.NewClient(apiKey,
+		books.WithHTTPClient(&http.Client{Timeout: *timeout}),
+		books.WithRequestCoalescing(),
+		books.WithStatusErrors(),
+		books.WithMaxResponseSize(16<<20),
+	)
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, NewServer(c)))
+}
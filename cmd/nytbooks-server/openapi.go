@@ -0,0 +1,173 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+)
+
+// schemaRef is the JSON pointer of a component schema
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+var dateType = reflect.TypeOf(Date{})
+
+// schemas builds the component schemas of the v1 types
+type schemas map[string]interface{}
+
+// of returns the schema of t, adding named structs to the components
+func (s schemas) of(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == dateType:
+		return map[string]interface{}{"type": "string", "format": "date"}
+	case t.Kind() == reflect.Ptr:
+		schema := s.of(t.Elem())
+		if _, ok := schema["$ref"]; ok {
+			// siblings of $ref are ignored in OpenAPI 3.0
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case t.Kind() == reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		if _, ok := s[t.Name()]; !ok {
+			s[t.Name()] = nil // guards against recursive types
+			s[t.Name()] = s.object(t)
+		}
+		return schemaRef(t.Name())
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+
+	return map[string]interface{}{}
+}
+
+// object builds the schema of a struct from its json and doc tags,
+// embedded structs having their fields inlined
+func (s schemas) object(t reflect.Type) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	var add func(t reflect.Type)
+	add = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				add(f.Type)
+				continue
+			}
+			tag := f.Tag.Get("json")
+			if f.PkgPath != "" || tag == "-" {
+				continue
+			}
+			name := strings.Split(tag, ",")[0]
+			if name == "" {
+				name = f.Name
+			}
+
+			schema := s.of(f.Type)
+			if doc := f.Tag.Get("doc"); doc != "" {
+				if _, ok := schema["$ref"]; ok {
+					schema = map[string]interface{}{"allOf": []interface{}{schema}}
+				}
+				schema["description"] = doc
+			}
+			props[name] = schema
+			if !strings.Contains(tag, ",omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	add(t)
+
+	obj := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+
+	return obj
+}
+
+// openAPI generates the OpenAPI 3 document of routes
+func openAPI(routes []route) map[string]interface{} {
+	s := make(schemas)
+	paths := make(map[string]interface{})
+	for _, rt := range routes {
+		var params []interface{}
+		for _, seg := range strings.Split(rt.pattern, "/") {
+			if strings.HasPrefix(seg, "{") {
+				name := strings.Trim(seg, "{}")
+				params = append(params, map[string]interface{}{
+					"name": name, "in": "path", "required": true,
+					"description": pathParams[name],
+					"schema":      map[string]interface{}{"type": "string"},
+				})
+			}
+		}
+		for _, p := range rt.query {
+			params = append(params, map[string]interface{}{
+				"name": p.name, "in": "query", "required": p.required,
+				"description": p.doc,
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+
+		op := map[string]interface{}{
+			"operationId": rt.id,
+			"summary":     rt.summary,
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": rt.summary,
+					"content": jsonContent(map[string]interface{}{
+						"type":       "object",
+						"required":   []string{"data"},
+						"properties": map[string]interface{}{"data": s.of(reflect.TypeOf(rt.response))},
+					}),
+				},
+				"default": map[string]interface{}{"$ref": "#/components/responses/Error"},
+			},
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		paths[rt.pattern] = map[string]interface{}{"get": op}
+	}
+
+	errorSchema := s.of(reflect.TypeOf(Error{}))
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "nytbooks",
+			"version":     "1",
+			"description": "The New York Times Books API, in a stable and normalised form",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": s,
+			"responses": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"description": "Failed request, with the error envelope",
+					"content": jsonContent(map[string]interface{}{
+						"type":       "object",
+						"required":   []string{"error"},
+						"properties": map[string]interface{}{"error": errorSchema},
+					}),
+				},
+			},
+		},
+	}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
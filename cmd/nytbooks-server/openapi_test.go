@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	s, _ := newServer()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/openapi.json", nil))
+
+	var doc struct {
+		OpenAPI    string
+		Paths      map[string]map[string]json.RawMessage
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{}
+				Required   []string
+			}
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("got openapi %q", doc.OpenAPI)
+	}
+	for _, rt := range s.routes {
+		if _, ok := doc.Paths[rt.pattern]["get"]; !ok {
+			t.Errorf("no operation for %s", rt.pattern)
+		}
+	}
+	for _, name := range []string{"ListSummary", "List", "Entry", "Book", "Link", "BookDetail", "Appearance", "Author", "Review", "Dates", "Error"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("no schema for %s", name)
+		}
+	}
+
+	entry := doc.Components.Schemas["Entry"]
+	if p := entry.Properties["rank_last_week"]; p["type"] != "integer" || p["nullable"] != true || p["description"] == nil {
+		t.Errorf("got rank_last_week %v", p)
+	}
+	detail := doc.Components.Schemas["BookDetail"]
+	if p := detail.Properties["isbn13"]; p["type"] != "string" {
+		t.Errorf("got BookDetail without the embedded Book's fields: %v", detail.Properties)
+	}
+	if p := doc.Components.Schemas["List"].Properties["published_date"]; p["format"] != "date" {
+		t.Errorf("got published_date %v", p)
+	}
+	book := strings.Join(doc.Components.Schemas["Book"].Required, " ")
+	if !strings.Contains(book, "isbn13") || strings.Contains(book, "isbn10") {
+		t.Errorf("got required %s, want omitempty fields left out", book)
+	}
+
+	// every reference resolves
+	for _, ref := range strings.Split(rec.Body.String(), `"$ref": "`)[1:] {
+		ref = ref[:strings.Index(ref, `"`)]
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, ok := doc.Components.Schemas[name]; !ok && ref != "#/components/responses/Error" {
+			t.Errorf("dangling reference %s", ref)
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/analytics"
+	"github.com/eddogola/nytimesbooks/internal/singleflight"
+)
+
+// param is a path or query parameter of a route
+type param struct {
+	name     string
+	doc      string
+	required bool
+}
+
+// pathParams documents the parameters found in route patterns
+var pathParams = map[string]string{
+	"list": "Identifier or name of a list, e.g. hardcover-fiction or \"hc fiction\"",
+	"date": "A date as YYYY-MM-DD, the edition in effect on that date being served",
+	"isbn": "An ISBN-10 or ISBN-13",
+	"name": "Name of an author",
+}
+
+// route is an endpoint of the v1 API. Its response, the zero value
+// of the type served in the data envelope, documents it.
+type route struct {
+	id       string
+	pattern  string
+	summary  string
+	query    []param
+	response interface{}
+	handle   func(r *http.Request, vars map[string]string) (interface{}, error)
+}
+
+// match reports whether path matches the route's pattern,
+// returning the values of its parameters
+func (rt route) match(path string) (map[string]string, bool) {
+	want := strings.Split(strings.Trim(rt.pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+
+	vars := make(map[string]string)
+	for i, w := range want {
+		if strings.HasPrefix(w, "{") {
+			if got[i] == "" {
+				return nil, false
+			}
+			vars[strings.Trim(w, "{}")] = got[i]
+			continue
+		}
+		if w != got[i] {
+			return nil, false
+		}
+	}
+
+	return vars, true
+}
+
+// httpError is an error answered with its own status and code
+type httpError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func badRequest(code, format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+func notFound(code, format string, args ...interface{}) error {
+	return &httpError{status: http.StatusNotFound, code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// Server serves the v1 API from a Client
+type Server struct {
+	client *books.Client
+	routes []route
+	spec   []byte
+
+	// resolverTTL is how long the list names are kept
+	resolverTTL time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	resolver *books.ListResolver
+	loaded   time.Time
+	reload   singleflight.Group
+}
+
+// NewServer constructs a Server. The client should be made
+// WithStatusErrors, for API errors to be told apart.
+func NewServer(c *books.Client) *Server {
+	s := &Server{client: c, resolverTTL: time.Hour, now: time.Now}
+	s.routes = []route{
+		{
+			id:       "listLists",
+			pattern:  "/v1/lists",
+			summary:  "Every best sellers list",
+			response: []ListSummary{},
+			handle:   s.lists,
+		},
+		{
+			id:       "getCurrentList",
+			pattern:  "/v1/lists/{list}",
+			summary:  "The current edition of a list",
+			response: List{},
+			handle:   s.currentList,
+		},
+		{
+			id:       "listDates",
+			pattern:  "/v1/lists/{list}/dates",
+			summary:  "The dates of a list's editions",
+			query:    []param{{name: "from", doc: "Earliest date, as YYYY-MM-DD"}, {name: "to", doc: "Latest date, as YYYY-MM-DD"}},
+			response: Dates{},
+			handle:   s.dates,
+		},
+		{
+			id:       "getList",
+			pattern:  "/v1/lists/{list}/{date}",
+			summary:  "The edition of a list in effect on a date",
+			response: List{},
+			handle:   s.listOn,
+		},
+		{
+			id:       "getBook",
+			pattern:  "/v1/books/{isbn}",
+			summary:  "A book and its list appearances",
+			response: BookDetail{},
+			handle:   s.book,
+		},
+		{
+			id:       "listBookReviews",
+			pattern:  "/v1/books/{isbn}/reviews",
+			summary:  "The reviews of a book",
+			response: []Review{},
+			handle:   s.bookReviews,
+		},
+		{
+			id:       "getAuthor",
+			pattern:  "/v1/authors/{name}",
+			summary:  "An author's books that made the lists",
+			response: Author{},
+			handle:   s.author,
+		},
+		{
+			id:      "searchReviews",
+			pattern: "/v1/reviews",
+			summary: "Reviews by ISBN, title or author, one of them being required",
+			query: []param{
+				{name: "isbn", doc: "An ISBN-10 or ISBN-13"},
+				{name: "title", doc: "Title of the book"},
+				{name: "author", doc: "Name of the author"},
+			},
+			response: []Review{},
+			handle:   s.reviews,
+		},
+	}
+
+	spec, err := json.MarshalIndent(openAPI(s.routes), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	s.spec = spec
+
+	return s
+}
+
+// ServeHTTP answers with the data envelope {"data": ...} or the error
+// envelope {"error": {...}}. The OpenAPI document is on /v1/openapi.json.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, &httpError{status: http.StatusMethodNotAllowed, code: "method_not_allowed", msg: r.Method + " isn't allowed"})
+		return
+	}
+	if r.URL.Path == "/v1/openapi.json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.spec)
+		return
+	}
+
+	for _, rt := range s.routes {
+		vars, ok := rt.match(r.URL.Path)
+		if !ok {
+			continue
+		}
+		v, err := rt.handle(r, vars)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct {
+			Data interface{} `json:"data"`
+		}{v})
+		return
+	}
+
+	writeError(w, notFound("not_found", "no such path %s", r.URL.Path))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e, retryAfter := toError(err)
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	}
+	writeJSON(w, e.Status, struct {
+		Error Error `json:"error"`
+	}{e})
+}
+
+// toError maps an error, usually from the Client, to the error served
+func toError(err error) (Error, time.Duration) {
+	var he *httpError
+	var apiErr *books.APIError
+	switch {
+	case errors.As(err, &he):
+		return Error{Status: he.status, Code: he.code, Message: he.msg}, 0
+	case errors.Is(err, books.ErrUnknownList):
+		return Error{Status: http.StatusNotFound, Code: "unknown_list", Message: message(err)}, 0
+	case errors.Is(err, books.ErrAmbiguousList):
+		return Error{Status: http.StatusBadRequest, Code: "ambiguous_list", Message: message(err)}, 0
+	case errors.Is(err, books.ErrDateOutOfRange):
+		return Error{Status: http.StatusNotFound, Code: "date_out_of_range", Message: message(err)}, 0
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return Error{Status: http.StatusServiceUnavailable, Code: "rate_limited", Message: "the Books API quota is used up"}, apiErr.RetryAfter
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return Error{Status: http.StatusBadGateway, Code: "upstream_unauthorized", Message: "the Books API rejected the server's api key"}, 0
+		case apiErr.StatusCode == http.StatusNotFound:
+			return Error{Status: http.StatusNotFound, Code: "not_found", Message: apiErr.Message}, 0
+		}
+		return Error{Status: http.StatusBadGateway, Code: "upstream_error", Message: message(err)}, 0
+	}
+
+	switch books.ClassifyError(err, 0) {
+	case books.ClassTimeout:
+		return Error{Status: http.StatusGatewayTimeout, Code: "upstream_timeout", Message: "the Books API didn't answer in time"}, 0
+	case books.ClassNetwork:
+		return Error{Status: http.StatusBadGateway, Code: "upstream_unavailable", Message: "the Books API can't be reached"}, 0
+	case books.ClassTooLarge:
+		return Error{Status: http.StatusBadGateway, Code: "upstream_too_large", Message: message(err)}, 0
+	case books.ClassDecode:
+		return Error{Status: http.StatusBadGateway, Code: "upstream_invalid", Message: "the Books API answered with an invalid response"}, 0
+	case books.ClassCanceled:
+		return Error{Status: http.StatusServiceUnavailable, Code: "canceled", Message: "the request was canceled"}, 0
+	}
+
+	return Error{Status: http.StatusInternalServerError, Code: "internal", Message: "internal error"}, 0
+}
+
+// message drops the package prefix of the Client's errors
+func message(err error) string {
+	return strings.TrimPrefix(err.Error(), "books: ")
+}
+
+// listResolver returns the resolver of list names, reloading it once stale.
+// The names are fetched without holding s.mu, and requests coming while
+// they are get the stale resolver, if there is one.
+func (s *Server) listResolver() (*books.ListResolver, error) {
+	s.mu.Lock()
+	stale := s.resolver
+	fresh := stale != nil && s.now().Sub(s.loaded) < s.resolverTTL
+	s.mu.Unlock()
+	if fresh || stale != nil && s.reload.Waiting("names") > 0 {
+		return stale, nil
+	}
+
+	v, err, _ := s.reload.Do("names", func() (interface{}, error) {
+		r, err := s.client.NewListResolver()
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.resolver, s.loaded = r, s.now()
+		s.mu.Unlock()
+		return r, nil
+	})
+	if err != nil {
+		if stale != nil {
+			// a stale resolver beats none
+			return stale, nil
+		}
+		return nil, err
+	}
+
+	return v.(*books.ListResolver), nil
+}
+
+func (s *Server) resolve(name string) (*books.ListResolver, books.ListInfo, error) {
+	r, err := s.listResolver()
+	if err != nil {
+		return nil, books.ListInfo{}, err
+	}
+	info, err := r.Resolve(name)
+
+	return r, info, err
+}
+
+func (s *Server) lists(r *http.Request, _ map[string]string) (interface{}, error) {
+	res, err := s.listResolver()
+	if err != nil {
+		return nil, err
+	}
+	out := []ListSummary{}
+	for _, l := range res.Lists() {
+		out = append(out, fromListInfo(l))
+	}
+
+	return out, nil
+}
+
+func (s *Server) currentList(r *http.Request, vars map[string]string) (interface{}, error) {
+	_, info, err := s.resolve(vars["list"])
+	if err != nil {
+		return nil, err
+	}
+	l, err := s.client.GetBestSellersListByDate("current", string(info.Name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromList(string(info.Name), l), nil
+}
+
+func (s *Server) listOn(r *http.Request, vars map[string]string) (interface{}, error) {
+	date, err := queryDate("date", vars["date"])
+	if err != nil {
+		return nil, err
+	}
+	res, info, err := s.resolve(vars["list"])
+	if err != nil {
+		return nil, err
+	}
+	l, err := res.GetBestSellersListOn(date, string(info.Name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromList(string(info.Name), l), nil
+}
+
+func (s *Server) dates(r *http.Request, vars map[string]string) (interface{}, error) {
+	_, info, err := s.resolve(vars["list"])
+	if err != nil {
+		return nil, err
+	}
+	from, to := info.Oldest, info.Newest
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = queryDate("from", v); err != nil {
+			return nil, err
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = queryDate("to", v); err != nil {
+			return nil, err
+		}
+	}
+
+	out := Dates{ListID: string(info.Name), Dates: []Date{}}
+	for _, d := range info.Editions(from, to) {
+		out.Dates = append(out.Dates, Date{d})
+	}
+
+	return out, nil
+}
+
+func queryDate(name, v string) (time.Time, error) {
+	t, err := time.Parse(books.DateLayout, v)
+	if err != nil {
+		return time.Time{}, badRequest("invalid_date", "%s must be a date as YYYY-MM-DD, not %q", name, v)
+	}
+
+	return t, nil
+}
+
+var isbnPattern = regexp.MustCompile(`^(\d{9}[\dX]|\d{13})$`)
+
+// normalizeISBN drops the hyphens and spaces of an ISBN and checks its form
+func normalizeISBN(isbn string) (string, error) {
+	isbn = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+	if !isbnPattern.MatchString(isbn) {
+		return "", badRequest("invalid_isbn", "%q isn't an ISBN-10 or ISBN-13", isbn)
+	}
+
+	return isbn, nil
+}
+
+// history searches the history, converting the books found
+func (s *Server) history(qp books.QueryParam) ([]BookDetail, error) {
+	res, err := s.listResolver()
+	if err != nil {
+		return nil, err
+	}
+	hist, err := s.client.GetBestSellersListHistory(qp)
+	if err != nil {
+		return nil, err
+	}
+	out := []BookDetail{}
+	for _, b := range hist.Results {
+		out = append(out, fromHistoryBook(b, res))
+	}
+
+	return out, nil
+}
+
+func (s *Server) book(r *http.Request, vars map[string]string) (interface{}, error) {
+	isbn, err := normalizeISBN(vars["isbn"])
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.history(books.QueryParam{"isbn": isbn})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, notFound("book_not_found", "no book with ISBN %s made the lists", isbn)
+	}
+
+	return found[0], nil
+}
+
+func (s *Server) author(r *http.Request, vars map[string]string) (interface{}, error) {
+	name := analytics.NormalizeAuthor(vars["name"])
+	found, err := s.history(books.QueryParam{"author": name})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, notFound("author_not_found", "no book by %s made the lists", name)
+	}
+
+	return Author{Name: name, Books: found}, nil
+}
+
+func (s *Server) bookReviews(r *http.Request, vars map[string]string) (interface{}, error) {
+	isbn, err := normalizeISBN(vars["isbn"])
+	if err != nil {
+		return nil, err
+	}
+
+	return s.searchReviews(books.QueryParam{"isbn": isbn})
+}
+
+func (s *Server) reviews(r *http.Request, _ map[string]string) (interface{}, error) {
+	qp := books.QueryParam{}
+	q := r.URL.Query()
+	if v := q.Get("isbn"); v != "" {
+		isbn, err := normalizeISBN(v)
+		if err != nil {
+			return nil, err
+		}
+		qp["isbn"] = isbn
+	}
+	for _, name := range []string{"title", "author"} {
+		if v := q.Get(name); v != "" {
+			qp[name] = v
+		}
+	}
+	if len(qp) == 0 {
+		return nil, badRequest("missing_parameter", "one of isbn, title or author is required")
+	}
+
+	return s.searchReviews(qp)
+}
+
+func (s *Server) searchReviews(qp books.QueryParam) ([]Review, error) {
+	res, err := s.client.GetReviews(qp)
+	if err != nil {
+		return nil, err
+	}
+	out := []Review{}
+	for _, r := range res.Results {
+		out = append(out, fromReview(r))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// fakeAPI answers the Books API paths with the testdata, or with
+// status for every path when it is set
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []*url.URL
+	status   int
+	body     string
+	header   http.Header
+	// empty makes history searches find nothing
+	empty bool
+}
+
+func (f *fakeAPI) Do(r *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL)
+	f.mu.Unlock()
+
+	if f.status != 0 {
+		return &http.Response{StatusCode: f.status, Header: f.header, Body: ioutil.NopCloser(strings.NewReader(f.body))}, nil
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/svc/books/v3")
+	var file string
+	switch {
+	case path == books.NamesEndpoint:
+		file = "names.json"
+	case path == books.HistoryEndpoint:
+		if f.empty {
+			return response(`{"status":"OK","num_results":0,"results":[]}`), nil
+		}
+		file = "history.json"
+	case path == books.ReviewsEndpoint:
+		file = "reviews.json"
+	case strings.HasPrefix(path, "/lists/"):
+		file = "list_by_date.json"
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader("{}"))}, nil
+	}
+	data, err := ioutil.ReadFile("../../testdata/" + file)
+	if err != nil {
+		return nil, err
+	}
+
+	return response(string(data)), nil
+}
+
+func response(body string) *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(body))}
+}
+
+func newServer() (*Server, *fakeAPI) {
+	api := &fakeAPI{}
+	c := books.NewClient("apikey", books.WithHTTPClient(api), books.WithStatusErrors())
+
+	return NewServer(c), api
+}
+
+// get serves target, decoding the data or error envelope into v
+func get(t *testing.T, s *Server, target string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("%s: got Content-Type %q", target, ct)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+	}
+
+	return rec
+}
+
+type errorEnvelope struct {
+	Error Error `json:"error"`
+}
+
+func TestLists(t *testing.T) {
+	s, _ := newServer()
+	var resp struct{ Data []ListSummary }
+	rec := get(t, s, "/v1/lists", &resp)
+	if rec.Code != http.StatusOK || len(resp.Data) != 1 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	want := `{"id":"combined-print-and-e-book-fiction","name":"Combined Print \u0026 E-Book Fiction","frequency":"weekly","first_published":"2011-02-13","last_published":"2016-03-20"}`
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("got %s, want %s", rec.Body, want)
+	}
+}
+
+func TestList(t *testing.T) {
+	s, api := newServer()
+	var resp struct {
+		Data map[string]interface{}
+	}
+	rec := get(t, s, "/v1/lists/combined%20fiction/2016-03-01", &resp)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+
+	// the resolver's names, then the edition in effect on the date
+	if len(api.requests) != 2 || api.requests[1].Path != "/svc/books/v3/lists/2016-02-28/combined-print-and-e-book-fiction.json" {
+		t.Errorf("got requests %v", api.requests)
+	}
+	if resp.Data["id"] != "combined-print-and-e-book-fiction" || resp.Data["published_date"] != "2016-01-03" {
+		t.Errorf("got %v", resp.Data)
+	}
+	entry := resp.Data["entries"].([]interface{})[0].(map[string]interface{})
+	book := entry["book"].(map[string]interface{})
+	if entry["rank"] != 1.0 || entry["rank_last_week"] != nil || entry["weeks_on_list"] != 60.0 {
+		t.Errorf("got entry %v", entry)
+	}
+	if book["isbn13"] != "9780553418026" || book["authors"].([]interface{})[0] != "Andy Weir" {
+		t.Errorf("got book %v", book)
+	}
+	if _, ok := book["price"]; ok {
+		t.Errorf("got a price for an unpriced book: %v", book)
+	}
+	if links := book["links"].([]interface{}); len(links) != 1 || links[0].(map[string]interface{})["rel"] != "buy" {
+		t.Errorf("got links %v", links)
+	}
+
+	// the resolver is kept
+	get(t, s, "/v1/lists/combined-print-and-e-book-fiction", nil)
+	if len(api.requests) != 3 || !strings.HasSuffix(api.requests[2].Path, "/lists/current/combined-print-and-e-book-fiction.json") {
+		t.Errorf("got requests %v", api.requests)
+	}
+}
+
+func TestDates(t *testing.T) {
+	s, _ := newServer()
+	var resp struct{ Data Dates }
+	get(t, s, "/v1/lists/combined-print-and-e-book-fiction/dates?from=2016-03-01", &resp)
+	var dates []string
+	for _, d := range resp.Data.Dates {
+		dates = append(dates, d.Format(books.DateLayout))
+	}
+	if got := strings.Join(dates, " "); got != "2016-03-06 2016-03-13 2016-03-20" {
+		t.Errorf("got dates %s", got)
+	}
+}
+
+func TestBook(t *testing.T) {
+	s, api := newServer()
+	var resp struct{ Data BookDetail }
+	get(t, s, "/v1/books/978-0-399-16927-4", &resp)
+	if api.requests[1].Query().Get("isbn") != "9780399169274" {
+		t.Errorf("searched %v, want the ISBN without hyphens", api.requests[1])
+	}
+	b := resp.Data
+	if b.ISBN13 != "9780399169274" || b.Title != "#GIRLBOSS" || len(b.Appearances) != 1 {
+		t.Fatalf("got %+v", b)
+	}
+	if a := b.Appearances[0]; a.ListName != "Business" || a.Rank != 8 || a.PublishedDate.Format(books.DateLayout) != "2016-03-13" {
+		t.Errorf("got appearance %+v", a)
+	}
+
+	var reviews struct{ Data []Review }
+	get(t, s, "/v1/books/9780307476463/reviews", &reviews)
+	if len(reviews.Data) != 1 || reviews.Data[0].Byline != "Janet Maslin" {
+		t.Errorf("got reviews %+v", reviews.Data)
+	}
+}
+
+func TestAuthor(t *testing.T) {
+	s, api := newServer()
+	var resp struct{ Data Author }
+	get(t, s, "/v1/authors/AMORUSO,%20SOPHIA", &resp)
+	if resp.Data.Name != "Sophia Amoruso" || len(resp.Data.Books) != 1 {
+		t.Errorf("got %+v", resp.Data)
+	}
+	if got := api.requests[1].Query().Get("author"); got != "Sophia Amoruso" {
+		t.Errorf("searched for %q", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s, api := newServer()
+	cases := []struct {
+		target string
+		status int
+		code   string
+	}{
+		{"/v1/nope", http.StatusNotFound, "not_found"},
+		{"/v1/lists/poetry", http.StatusNotFound, "unknown_list"},
+		{"/v1/lists/combined-print-and-e-book-fiction/2001-01-01", http.StatusNotFound, "date_out_of_range"},
+		{"/v1/lists/combined-print-and-e-book-fiction/yesterday", http.StatusBadRequest, "invalid_date"},
+		{"/v1/books/12345", http.StatusBadRequest, "invalid_isbn"},
+		{"/v1/reviews", http.StatusBadRequest, "missing_parameter"},
+	}
+	for _, tc := range cases {
+		var resp errorEnvelope
+		rec := get(t, s, tc.target, &resp)
+		if rec.Code != tc.status || resp.Error.Status != tc.status || resp.Error.Code != tc.code || resp.Error.Message == "" {
+			t.Errorf("%s: got %d %s, want %d %s", tc.target, rec.Code, rec.Body, tc.status, tc.code)
+		}
+	}
+
+	api.empty = true
+	var resp errorEnvelope
+	if rec := get(t, s, "/v1/books/9780000000000", &resp); rec.Code != http.StatusNotFound || resp.Error.Code != "book_not_found" {
+		t.Errorf("got %d %s", rec.Code, rec.Body)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/lists", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("got %d", rec.Code)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	cases := []struct {
+		status     int
+		header     http.Header
+		body       string
+		want       int
+		code       string
+		retryAfter string
+	}{
+		{http.StatusTooManyRequests, http.Header{"Retry-After": {"60"}}, `{"fault":{"faultstring":"Rate limit quota violation"}}`, http.StatusServiceUnavailable, "rate_limited", "60"},
+		{http.StatusUnauthorized, nil, `{"fault":{"faultstring":"Invalid ApiKey"}}`, http.StatusBadGateway, "upstream_unauthorized", ""},
+		{http.StatusInternalServerError, nil, ``, http.StatusBadGateway, "upstream_error", ""},
+		{http.StatusOK, nil, `{"status": "OK", "results": [`, http.StatusBadGateway, "upstream_invalid", ""},
+	}
+	for _, tc := range cases {
+		s, api := newServer()
+		api.status, api.header, api.body = tc.status, tc.header, tc.body
+
+		var resp errorEnvelope
+		rec := get(t, s, "/v1/reviews?title=1Q84", &resp)
+		if rec.Code != tc.want || resp.Error.Code != tc.code || rec.Header().Get("Retry-After") != tc.retryAfter {
+			t.Errorf("upstream %d: got %d %s, Retry-After %q", tc.status, rec.Code, rec.Body, rec.Header().Get("Retry-After"))
+		}
+	}
+}
+
+// blockingNames holds the names calls until released
+type blockingNames struct {
+	*fakeAPI
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingNames) Do(r *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(r.URL.Path, books.NamesEndpoint) {
+		b.entered <- struct{}{}
+		<-b.release
+	}
+
+	return b.fakeAPI.Do(r)
+}
+
+func TestResolverReload(t *testing.T) {
+	api := &blockingNames{fakeAPI: &fakeAPI{}, entered: make(chan struct{}, 1), release: make(chan struct{})}
+	s := NewServer(books.NewClient("apikey", books.WithHTTPClient(api), books.WithStatusErrors()))
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	go func() {
+		<-api.entered
+		api.release <- struct{}{}
+	}()
+	if _, err := s.listResolver(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// while the stale names are reloaded, requests get them at once
+	now = now.Add(2 * time.Hour)
+	done := make(chan error)
+	go func() {
+		_, err := s.listResolver()
+		done <- err
+	}()
+	<-api.entered
+	if _, err := s.listResolver(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	api.release <- struct{}{}
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
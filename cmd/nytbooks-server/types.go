@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/analytics"
+)
+
+// The types below are the v1 schema. They only ever gain fields:
+// anything else is a new version. The doc tags end up in the
+// OpenAPI document.
+
+// Date is a calendar date, written as YYYY-MM-DD
+type Date struct {
+	time.Time
+}
+
+func parseDate(s string) Date {
+	t, err := time.Parse(books.DateLayout, s)
+	if err != nil {
+		return Date{}
+	}
+
+	return Date{t}
+}
+
+// MarshalJSON writes the date, or null for the zero Date
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + d.Format(books.DateLayout) + `"`), nil
+}
+
+// UnmarshalJSON reads a YYYY-MM-DD date, or null
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse(books.DateLayout, s)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+
+	return nil
+}
+
+// ListSummary describes a best sellers list
+type ListSummary struct {
+	ID             string `json:"id" doc:"Identifier of the list, as used in paths"`
+	Name           string `json:"name" doc:"Display name of the list"`
+	Frequency      string `json:"frequency" doc:"How often the list is published, weekly or monthly"`
+	FirstPublished Date   `json:"first_published" doc:"Date of the oldest edition"`
+	LastPublished  Date   `json:"last_published" doc:"Date of the newest edition"`
+}
+
+// List is an edition of a best sellers list
+type List struct {
+	ID              string  `json:"id" doc:"Identifier of the list"`
+	Name            string  `json:"name" doc:"Display name of the list"`
+	PublishedDate   Date    `json:"published_date" doc:"Date the edition was published"`
+	BestsellersDate Date    `json:"bestsellers_date" doc:"End of the week of sales the edition covers"`
+	Entries         []Entry `json:"entries" doc:"Books of the edition by rank, with published corrections applied"`
+}
+
+// Entry is a book at a rank of a list
+type Entry struct {
+	Rank         int  `json:"rank"`
+	RankLastWeek *int `json:"rank_last_week" doc:"Rank in the previous edition, null for books new to the list"`
+	WeeksOnList  int  `json:"weeks_on_list"`
+	Book         Book `json:"book"`
+}
+
+// Book is a book, identified by its primary ISBN
+type Book struct {
+	ISBN13      string   `json:"isbn13" doc:"Primary ISBN-13"`
+	ISBN10      string   `json:"isbn10,omitempty" doc:"Primary ISBN-10"`
+	OtherISBNs  []string `json:"other_isbns,omitempty" doc:"ISBN-13s of other editions"`
+	Title       string   `json:"title"`
+	Authors     []string `json:"authors" doc:"Authors, with names normalised"`
+	Contributor string   `json:"contributor,omitempty" doc:"Credits as printed, e.g. \"by Mo Willems; illustrated by Tony Fucile\""`
+	Publisher   string   `json:"publisher,omitempty"`
+	Description string   `json:"description,omitempty"`
+	AgeGroup    string   `json:"age_group,omitempty"`
+	Price       float64  `json:"price,omitempty" doc:"List price in US dollars, when known"`
+	ImageURL    string   `json:"image_url,omitempty" doc:"Cover image"`
+	Links       []Link   `json:"links,omitempty"`
+}
+
+// Link is a link about a book
+type Link struct {
+	Rel string `json:"rel" doc:"What the link is: buy, review, sunday_review, first_chapter or article"`
+	URL string `json:"url"`
+}
+
+// BookDetail is a book with every list appearance
+type BookDetail struct {
+	Book
+	Appearances []Appearance `json:"appearances" doc:"Weeks the book spent on lists, newest first"`
+}
+
+// Appearance is a week a book spent on a list
+type Appearance struct {
+	ListID          string `json:"list_id,omitempty" doc:"Identifier of the list, when it is still published"`
+	ListName        string `json:"list_name" doc:"Display name of the list"`
+	Rank            int    `json:"rank"`
+	WeeksOnList     int    `json:"weeks_on_list"`
+	PublishedDate   Date   `json:"published_date"`
+	BestsellersDate Date   `json:"bestsellers_date"`
+}
+
+// Author is an author and the books of theirs that made the lists
+type Author struct {
+	Name  string       `json:"name" doc:"Name of the author, normalised"`
+	Books []BookDetail `json:"books"`
+}
+
+// Review is a review of a book in The New York Times
+type Review struct {
+	URL           string   `json:"url"`
+	Byline        string   `json:"byline" doc:"Name of the reviewer, normalised"`
+	Summary       string   `json:"summary,omitempty"`
+	PublishedDate Date     `json:"published_date"`
+	BookTitle     string   `json:"book_title"`
+	BookAuthor    string   `json:"book_author"`
+	ISBN13s       []string `json:"isbn13s"`
+}
+
+// Dates are the dates of a list's editions
+type Dates struct {
+	ListID string `json:"list_id" doc:"Identifier of the list"`
+	Dates  []Date `json:"dates" doc:"Edition dates, oldest first"`
+}
+
+// Error is what failed requests are answered with
+type Error struct {
+	Status  int    `json:"status" doc:"HTTP status of the response"`
+	Code    string `json:"code" doc:"Stable identifier of the error, e.g. unknown_list or rate_limited"`
+	Message string `json:"message" doc:"Explanation for humans, which may change"`
+}
+
+func fromListInfo(l books.ListInfo) ListSummary {
+	return ListSummary{
+		ID:             string(l.Name),
+		Name:           l.DisplayName,
+		Frequency:      strings.ToLower(string(l.Frequency)),
+		FirstPublished: Date{l.Oldest},
+		LastPublished:  Date{l.Newest},
+	}
+}
+
+func fromList(id string, l *books.ListByDate) List {
+	// ApplyCorrections changes the list in place
+	res := l.Results
+	res.Books = append([]books.ListBook(nil), res.Books...)
+	corrected := books.ListByDate{Results: res}
+	corrected.ApplyCorrections(res.Corrections)
+
+	out := List{
+		ID:              id,
+		Name:            res.DisplayName,
+		PublishedDate:   parseDate(res.PublishedDate),
+		BestsellersDate: parseDate(res.BestsellersDate),
+		Entries:         []Entry{},
+	}
+	for _, b := range corrected.Results.Books {
+		e := Entry{Rank: b.Rank, WeeksOnList: b.WeeksOnList, Book: fromListBook(b)}
+		if b.RankLastWeek > 0 {
+			last := b.RankLastWeek
+			e.RankLastWeek = &last
+		}
+		out.Entries = append(out.Entries, e)
+	}
+
+	return out
+}
+
+func fromListBook(b books.ListBook) Book {
+	out := Book{
+		ISBN13:      b.PrimaryISBN13,
+		ISBN10:      b.PrimaryISBN10,
+		Title:       b.Title,
+		Authors:     authors(b.Author),
+		Contributor: b.Contributor,
+		Publisher:   b.Publisher,
+		Description: b.Description,
+		AgeGroup:    b.AgeGroup,
+		Price:       float64(b.Price),
+		ImageURL:    b.BookImage,
+	}
+	for _, i := range b.ISBNs {
+		out.OtherISBNs = appendISBN(out.OtherISBNs, i.ISBN13, out.ISBN13)
+	}
+	for _, l := range []Link{
+		{"buy", b.AmazonProductURL},
+		{"review", b.BookReviewLink},
+		{"sunday_review", b.SundayReviewLink},
+		{"first_chapter", b.FirstChapterLink},
+		{"article", b.ArticleChapterLink},
+	} {
+		if l.URL != "" {
+			out.Links = append(out.Links, l)
+		}
+	}
+
+	return out
+}
+
+// fromHistoryBook converts a book of a history search, looking
+// up the identifiers of the lists it appeared on with r
+func fromHistoryBook(b books.HistoryBook, r *books.ListResolver) BookDetail {
+	out := BookDetail{
+		Book: Book{
+			Title:       b.Title,
+			Authors:     authors(b.Author),
+			Contributor: b.Contributor,
+			Publisher:   b.Publisher,
+			Description: b.Description,
+			AgeGroup:    b.AgeGroup,
+			Price:       float64(b.Price),
+		},
+		Appearances: []Appearance{},
+	}
+	for _, i := range b.ISBNs {
+		if out.ISBN13 == "" {
+			out.ISBN13, out.ISBN10 = i.ISBN13, i.ISBN10
+			continue
+		}
+		out.OtherISBNs = appendISBN(out.OtherISBNs, i.ISBN13, out.ISBN13)
+	}
+	for _, rh := range b.RanksHistory {
+		if out.ISBN13 == "" {
+			out.ISBN13, out.ISBN10 = rh.PrimaryISBN13, rh.PrimaryISBN10
+		}
+		a := Appearance{
+			ListName:        rh.DisplayName,
+			Rank:            rh.Rank,
+			WeeksOnList:     rh.WeeksOnList,
+			PublishedDate:   parseDate(rh.PublishedDate),
+			BestsellersDate: parseDate(rh.BestsellersDate),
+		}
+		if info, err := r.Resolve(rh.ListName); err == nil {
+			a.ListID = string(info.Name)
+		}
+		out.Appearances = append(out.Appearances, a)
+	}
+
+	return out
+}
+
+func fromReview(r books.Review) Review {
+	out := Review{
+		URL:           r.URL,
+		Byline:        analytics.NormalizeAuthor(r.ByLine),
+		Summary:       r.Summary,
+		PublishedDate: parseDate(r.PublicationDt),
+		BookTitle:     r.BookTitle,
+		BookAuthor:    r.BookAuthor,
+		ISBN13s:       r.ISBN13,
+	}
+	if out.ISBN13s == nil {
+		out.ISBN13s = []string{}
+	}
+
+	return out
+}
+
+func authors(s string) []string {
+	names, with := analytics.SplitAuthors(s)
+	names = append(names, with...)
+	if names == nil {
+		return []string{}
+	}
+
+	return names
+}
+
+func appendISBN(isbns []string, isbn, primary string) []string {
+	if isbn == "" || isbn == primary {
+		return isbns
+	}
+	for _, i := range isbns {
+		if i == isbn {
+			return isbns
+		}
+	}
+
+	return append(isbns, isbn)
+}
@@ -0,0 +1,138 @@
+// Command nytbooks-watchlist prints or emails a digest of the books of a
+// watchlist found on this week's best sellers lists.
+//
+//	nytbooks-watchlist -watchlist editors.yaml -history seen.json
+//	nytbooks-watchlist -watchlist editors.yaml -to a@example.com,b@example.com -smtp mail:25
+//
+// The api key is read from the NYT_API_KEY environment variable, and the
+// SMTP password, if any, from SMTP_PASSWORD. Without -smtp, emails are
+// printed rather than sent.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/watchlist"
+)
+
+func main() {
+	file := flag.String("watchlist", "watchlist.yaml", "watchlist file, JSON or YAML")
+	history := flag.String("history", "", "file remembering the books already seen, to flag first appearances")
+	to := flag.String("to", "", "comma separated addresses to email the digest to, instead of printing it")
+	from := flag.String("from", "nytbooks@localhost", "sender of the digest emails")
+	smtpAddr := flag.String("smtp", "", "host:port of the SMTP server, emails are printed if empty")
+	smtpUser := flag.String("smtp-user", "", "SMTP user, if the server requires authentication")
+	flag.Parse()
+
+	apiKey := os.Getenv("NYT_API_KEY")
+	if apiKey == "" {
+		log.Fatal("NYT_API_KEY is not set")
+	}
+
+	w, err := watchlist.Load(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	h := watchlist.History(nil)
+	if *history != "" {
+		if h, err = watchlist.LoadHistory(*history); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	c := books.NewClient(apiKey, books.WithHTTPClient(&http.Client{Timeout: time.Minute}))
+	overview, err := fullOverview(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	matches, err := w.MatchOverview(overview, h)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	subject := digestSubject(w.Name, overview.Results.PublishedDate)
+	if *to == "" {
+		err = watchlist.WriteDigest(os.Stdout, subject, matches)
+	} else {
+		err = watchlist.SendDigest(mailer(*smtpAddr, *smtpUser, *from), strings.Split(*to, ","), subject, matches)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *history != "" {
+		if err := watchlist.SaveHistory(*history, h); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// digestSubject is the subject of the digest of the named watchlist
+// for the lists published on date
+func digestSubject(name, date string) string {
+	subject := "Best sellers watchlist"
+	if name != "" {
+		subject = name + " watchlist"
+	}
+	if date != "" {
+		subject += " for " + date
+	}
+
+	return subject
+}
+
+// fullOverview gets every book of every list. The stream doesn't carry the
+// published date, so the top 5 overview is asked for it first, and the full
+// overview of that same date is streamed.
+func fullOverview(c *books.Client) (*books.Overview, error) {
+	top, err := c.GetOverview(nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting the published date: %v", err)
+	}
+	date := top.Results.PublishedDate
+	var qp books.QueryParam
+	if date != "" {
+		qp = books.QueryParam{"published_date": date}
+	}
+
+	var o books.Overview
+	o.Results.PublishedDate = date
+	o.Results.BestsellersDate = top.Results.BestsellersDate
+	err = c.StreamFullOverview(qp, func(l books.OverviewList, b books.OverviewBook) error {
+		lists := o.Results.Lists
+		if len(lists) == 0 || lists[len(lists)-1].ListName != l.ListName {
+			o.Results.Lists = append(lists, l)
+		}
+		last := &o.Results.Lists[len(o.Results.Lists)-1]
+		last.Books = append(last.Books, b)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting the lists: %v", err)
+	}
+
+	return &o, nil
+}
+
+func mailer(addr, user, from string) watchlist.Mailer {
+	if addr == "" {
+		return &watchlist.WriterMailer{W: os.Stdout, From: from}
+	}
+
+	m := &watchlist.SMTPMailer{Addr: addr, From: from}
+	if user != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		m.Auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	return m
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+const (
+	overviewJSON = `{"status": "OK", "results": {"bestsellers_date": "2016-03-05", "published_date": "2016-03-20", "lists": [
+		{"list_id": 704, "list_name": "Combined Print and E-Book Fiction", "books": [{"rank": 1, "title": "THE GANGSTER"}]}
+	]}}`
+	fullOverviewJSON = `{"status": "OK", "results": {"bestsellers_date": "2016-03-05", "published_date": "2016-03-20", "lists": [
+		{"list_id": 704, "list_name": "Combined Print and E-Book Fiction", "books": [{"rank": 1, "title": "THE GANGSTER"}, {"rank": 6, "title": "NEST"}]},
+		{"list_id": 2, "list_name": "Hardcover Nonfiction", "books": [{"rank": 1, "title": "GIRLBOSS"}]}
+	]}}`
+)
+
+type fakeAPI struct {
+	requests []*url.URL
+}
+
+func (f *fakeAPI) Do(r *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, r.URL)
+	body := overviewJSON
+	if strings.HasSuffix(r.URL.Path, books.FullOverviewEndpoint) {
+		body = fullOverviewJSON
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestFullOverview(t *testing.T) {
+	api := &fakeAPI{}
+	c := books.NewClient("apikey", books.WithHTTPClient(api))
+
+	o, err := fullOverview(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o.Results.PublishedDate != "2016-03-20" {
+		t.Errorf("got published date %q, want 2016-03-20", o.Results.PublishedDate)
+	}
+	if len(o.Results.Lists) != 2 || len(o.Results.Lists[0].Books) != 2 {
+		t.Errorf("got lists %+v", o.Results.Lists)
+	}
+	if len(api.requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(api.requests))
+	}
+	if got := api.requests[1].Query().Get("published_date"); got != "2016-03-20" {
+		t.Errorf("streamed the full overview of %q, want 2016-03-20", got)
+	}
+
+	want := "Editors watchlist for 2016-03-20"
+	if got := digestSubject("Editors", o.Results.PublishedDate); got != want {
+		t.Errorf("got subject %q, want %q", got, want)
+	}
+}
+
+func TestDigestSubject(t *testing.T) {
+	tests := []struct {
+		name, date, want string
+	}{
+		{"", "", "Best sellers watchlist"},
+		{"Editors", "", "Editors watchlist"},
+		{"", "2016-03-20", "Best sellers watchlist for 2016-03-20"},
+	}
+	for _, tt := range tests {
+		if got := digestSubject(tt.name, tt.date); got != tt.want {
+			t.Errorf("digestSubject(%q, %q) = %q, want %q", tt.name, tt.date, got, tt.want)
+		}
+	}
+}
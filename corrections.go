@@ -0,0 +1,99 @@
+package books
+
+import (
+	"sort"
+	"strings"
+)
+
+// ApplyCorrections applies corrections, usually taken from a fresh fetch
+// of the same list edition, to a previously fetched list.
+//
+// A correction naming a book of the list by ISBN or title moves it to the
+// corrected rank, swapping places with the book there. Otherwise the book
+// at the corrected rank is replaced by the corrected title and ISBNs, the
+// rest of its details being unknown. Corrections are applied oldest first,
+// and the ones matching no book nor rank are returned. Applying the same
+// corrections again changes nothing.
+func (l *ListByDate) ApplyCorrections(corrections []Correction) []Correction {
+	sorted := make([]Correction, len(corrections))
+	copy(sorted, corrections)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CorrectionDate < sorted[j].CorrectionDate
+	})
+
+	var unmatched []Correction
+	books := l.Results.Books
+	for _, c := range sorted {
+		i := findBook(books, c)
+		at := -1
+		for k := range books {
+			if c.Rank > 0 && books[k].Rank == c.Rank {
+				at = k
+				break
+			}
+		}
+
+		switch {
+		case i >= 0:
+			if c.Rank > 0 && at >= 0 && at != i {
+				books[at].Rank = books[i].Rank
+			}
+			if c.Rank > 0 {
+				books[i].Rank = c.Rank
+			}
+			if c.Title != "" {
+				books[i].Title = c.Title
+			}
+		case at >= 0:
+			books[at] = ListBook{
+				Rank:          c.Rank,
+				Title:         c.Title,
+				PrimaryISBN13: c.PrimaryISBN13,
+				PrimaryISBN10: c.PrimaryISBN10,
+			}
+		default:
+			unmatched = append(unmatched, c)
+		}
+	}
+
+	sort.SliceStable(books, func(i, j int) bool { return books[i].Rank < books[j].Rank })
+	for _, c := range corrections {
+		if !hasCorrection(l.Results.Corrections, c) {
+			l.Results.Corrections = append(l.Results.Corrections, c)
+		}
+	}
+
+	return unmatched
+}
+
+func hasCorrection(corrections []Correction, c Correction) bool {
+	for _, have := range corrections {
+		if have == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findBook returns the index of the book a correction is about, or -1
+func findBook(books []ListBook, c Correction) int {
+	for i, b := range books {
+		switch {
+		case c.PrimaryISBN13 != "" && b.PrimaryISBN13 == c.PrimaryISBN13:
+			return i
+		case c.PrimaryISBN10 != "" && b.PrimaryISBN10 == c.PrimaryISBN10:
+			return i
+		}
+	}
+	if c.PrimaryISBN13 != "" || c.PrimaryISBN10 != "" {
+		return -1
+	}
+	for i, b := range books {
+		if c.Title != "" && strings.EqualFold(b.Title, c.Title) {
+			return i
+		}
+	}
+
+	return -1
+}
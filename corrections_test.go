@@ -0,0 +1,55 @@
+package books
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyCorrections(t *testing.T) {
+	stored := `{"results": {"list_name": "Hardcover Fiction", "books": [
+		{"rank": 1, "title": "THE GANGSTER", "primary_isbn13": "9780399175954"},
+		{"rank": 2, "title": "NEST", "primary_isbn13": "9780812987188"},
+		{"rank": 3, "title": "THE MARTAIN", "primary_isbn13": "9780553418026"},
+		{"rank": 4, "title": "DRAGON TEETH", "primary_isbn13": "9780062473356"}]}}`
+	fresh := `{"results": {"list_name": "Hardcover Fiction", "corrections": [
+		{"title": "THE MARTIAN", "primary_isbn13": "9780553418026", "rank": 1, "correction_text": "THE MARTIAN was ranked #1.", "correction_date": "2016-03-21"},
+		{"title": "FOURTH WING", "primary_isbn13": "9781649374042", "rank": 4, "correction_text": "FOURTH WING was omitted.", "correction_date": "2016-03-22"},
+		{"title": "NOWHERE", "primary_isbn13": "9780000000000", "rank": 40, "correction_date": "2016-03-22"}]}}`
+
+	var list, update ListByDate
+	if err := json.Unmarshal([]byte(stored), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(fresh), &update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := update.Results.Corrections[0].CorrectionText; got != "THE MARTIAN was ranked #1." {
+		t.Errorf("correction not decoded, got text %q", got)
+	}
+
+	unmatched := list.ApplyCorrections(update.Results.Corrections)
+	if len(unmatched) != 1 || unmatched[0].Title != "NOWHERE" {
+		t.Errorf("got unmatched %+v, want NOWHERE", unmatched)
+	}
+
+	want := []string{"THE MARTIAN", "NEST", "THE GANGSTER", "FOURTH WING"}
+	for i, b := range list.Results.Books {
+		if b.Rank != i+1 || b.Title != want[i] {
+			t.Errorf("book %d: got #%d %v, want #%d %v", i, b.Rank, b.Title, i+1, want[i])
+		}
+	}
+	if len(list.Results.Corrections) != 3 {
+		t.Errorf("got %d corrections recorded, want 3", len(list.Results.Corrections))
+	}
+
+	// applying them again, e.g. to a cached list, changes nothing
+	list.ApplyCorrections(update.Results.Corrections)
+	for i, b := range list.Results.Books {
+		if b.Rank != i+1 || b.Title != want[i] {
+			t.Errorf("reapplied, book %d: got #%d %v, want #%d %v", i, b.Rank, b.Title, i+1, want[i])
+		}
+	}
+	if len(list.Results.Corrections) != 3 {
+		t.Errorf("reapplied, got %d corrections recorded, want 3", len(list.Results.Corrections))
+	}
+}
@@ -0,0 +1,147 @@
+package books
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Weekly lists publish every seven days, on the weekday of their newest edition.
+// Monthly lists are taken to publish on the same weekday of the same week
+// of every month as their newest edition, e.g. the second Sunday.
+
+// edition returns the i-th edition before the newest one, edition(0) being the newest
+func (l ListInfo) edition(i int) time.Time {
+	if l.Frequency != Monthly {
+		return l.Newest.AddDate(0, 0, -7*i)
+	}
+
+	week := (l.Newest.Day() - 1) / 7
+	first := time.Date(l.Newest.Year(), l.Newest.Month()-time.Month(i), 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(l.Newest.Weekday()) - int(first.Weekday()) + 7) % 7
+	d := first.AddDate(0, 0, offset+7*week)
+	// a fifth weekday doesn't exist in every month
+	if d.Month() != first.Month() {
+		d = d.AddDate(0, 0, -7)
+	}
+
+	return d
+}
+
+// index returns the index of the edition in effect on date,
+// which must not be after the newest edition
+func (l ListInfo) index(date time.Time) int {
+	days := int(l.Newest.Sub(date).Hours() / 24)
+	if l.Frequency != Monthly {
+		return (days + 6) / 7
+	}
+
+	i := days / 31
+	for l.edition(i).After(date) {
+		i++
+	}
+	for i > 0 && !l.edition(i-1).After(date) {
+		i--
+	}
+
+	return i
+}
+
+// EditionOn returns the edition of the list in effect on date:
+// the latest one published on or before it.
+func (l ListInfo) EditionOn(date time.Time) (time.Time, error) {
+	date = truncateDay(date)
+	if !date.Before(l.Newest) {
+		return l.Newest, nil
+	}
+	e := l.edition(l.index(date))
+	if e.Before(l.Oldest) {
+		return time.Time{}, l.outOfRange(date)
+	}
+
+	return e, nil
+}
+
+// NextEdition returns the first edition published after date.
+// It fails with ErrDateOutOfRange if that edition isn't out yet.
+func (l ListInfo) NextEdition(date time.Time) (time.Time, error) {
+	date = truncateDay(date)
+	if !date.Before(l.Newest) {
+		return time.Time{}, l.outOfRange(date)
+	}
+	if date.Before(l.Oldest) {
+		return l.Oldest, nil
+	}
+
+	return l.edition(l.index(date) - 1), nil
+}
+
+// PreviousEdition returns the last edition published before date
+func (l ListInfo) PreviousEdition(date time.Time) (time.Time, error) {
+	date = truncateDay(date)
+	i := 0
+	if !date.After(l.Newest) {
+		i = l.index(date)
+		if !l.edition(i).Before(date) {
+			i++
+		}
+	}
+	e := l.edition(i)
+	if e.Before(l.Oldest) {
+		return time.Time{}, l.outOfRange(date)
+	}
+
+	return e, nil
+}
+
+// Editions returns every edition published between from and to, inclusive,
+// oldest first.
+func (l ListInfo) Editions(from, to time.Time) []time.Time {
+	from, to = truncateDay(from), truncateDay(to)
+	if from.Before(l.Oldest) {
+		from = l.Oldest
+	}
+
+	var editions []time.Time
+	i := 0
+	if to.Before(l.Newest) {
+		i = l.index(to)
+	}
+	for e := l.edition(i); !e.Before(from); e = l.edition(i) {
+		editions = append(editions, e)
+		i++
+	}
+	// reverse into chronological order
+	for a, b := 0, len(editions)-1; a < b; a, b = a+1, b-1 {
+		editions[a], editions[b] = editions[b], editions[a]
+	}
+
+	return editions
+}
+
+func (l ListInfo) outOfRange(date time.Time) error {
+	return fmt.Errorf("%w: %s has editions from %s to %s, not %s", ErrDateOutOfRange,
+		l.Name, l.Oldest.Format(DateLayout), l.Newest.Format(DateLayout), date.Format(DateLayout))
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// GetBestSellersListOn gets the edition of list in effect on date,
+// answering questions like "what was #1 on 2015-07-04".
+func (r *ListResolver) GetBestSellersListOn(date time.Time, list string, qp QueryParam) (*ListByDate, error) {
+	if r.client == nil {
+		return nil, errors.New("books: list resolver has no client")
+	}
+	info, err := r.Resolve(list)
+	if err != nil {
+		return nil, err
+	}
+	edition, err := info.EditionOn(date)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.client.GetBestSellersListByDate(edition.Format(DateLayout), string(info.Name), qp)
+}
@@ -0,0 +1,118 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(DateLayout, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return d
+}
+
+func TestEditionsWeekly(t *testing.T) {
+	hc, err := newTestResolver(t).Resolve("hardcover-fiction")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 2015-07-04 is a Saturday, editions come out on Sundays
+	got, err := hc.EditionOn(date(t, "2015-07-04"))
+	if err != nil || got != date(t, "2015-06-28") {
+		t.Errorf("EditionOn = %v, %v, want 2015-06-28", got, err)
+	}
+	got, err = hc.EditionOn(date(t, "2015-07-05"))
+	if err != nil || got != date(t, "2015-07-05") {
+		t.Errorf("EditionOn = %v, %v, want 2015-07-05", got, err)
+	}
+	got, err = hc.NextEdition(date(t, "2015-07-04"))
+	if err != nil || got != date(t, "2015-07-05") {
+		t.Errorf("NextEdition = %v, %v, want 2015-07-05", got, err)
+	}
+	got, err = hc.PreviousEdition(date(t, "2015-07-05"))
+	if err != nil || got != date(t, "2015-06-28") {
+		t.Errorf("PreviousEdition = %v, %v, want 2015-06-28", got, err)
+	}
+	got, err = hc.EditionOn(date(t, "2020-01-01"))
+	if err != nil || got != hc.Newest {
+		t.Errorf("EditionOn = %v, %v, want the newest edition", got, err)
+	}
+
+	if _, err := hc.EditionOn(date(t, "2001-01-01")); !errors.Is(err, ErrDateOutOfRange) {
+		t.Errorf("got error %v, want %v", err, ErrDateOutOfRange)
+	}
+	if _, err := hc.NextEdition(hc.Newest); !errors.Is(err, ErrDateOutOfRange) {
+		t.Errorf("got error %v, want %v", err, ErrDateOutOfRange)
+	}
+
+	editions := hc.Editions(date(t, "2015-06-01"), date(t, "2015-06-30"))
+	want := []time.Time{date(t, "2015-06-07"), date(t, "2015-06-14"), date(t, "2015-06-21"), date(t, "2015-06-28")}
+	if !reflect.DeepEqual(editions, want) {
+		t.Errorf("got editions %v, want %v", editions, want)
+	}
+}
+
+func TestEditionsMonthly(t *testing.T) {
+	ya, err := newTestResolver(t).Resolve("young-adult-paperback-monthly")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the newest edition, 2021-07-11, is the second Sunday of the month
+	editions := ya.Editions(date(t, "2021-03-01"), date(t, "2021-06-30"))
+	want := []time.Time{date(t, "2021-03-14"), date(t, "2021-04-11"), date(t, "2021-05-09"), date(t, "2021-06-13")}
+	if !reflect.DeepEqual(editions, want) {
+		t.Errorf("got editions %v, want %v", editions, want)
+	}
+
+	got, err := ya.EditionOn(date(t, "2021-05-31"))
+	if err != nil || got != date(t, "2021-05-09") {
+		t.Errorf("EditionOn = %v, %v, want 2021-05-09", got, err)
+	}
+	got, err = ya.NextEdition(date(t, "2021-05-31"))
+	if err != nil || got != date(t, "2021-06-13") {
+		t.Errorf("NextEdition = %v, %v, want 2021-06-13", got, err)
+	}
+	got, err = ya.PreviousEdition(date(t, "2021-05-09"))
+	if err != nil || got != date(t, "2021-04-11") {
+		t.Errorf("PreviousEdition = %v, %v, want 2021-04-11", got, err)
+	}
+}
+
+func TestGetBestSellersListOn(t *testing.T) {
+	var requested string
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			data := `{"status": "OK"}`
+			if r.URL.Path == "/svc/books/v3"+NamesEndpoint {
+				data = namesJSON
+			} else {
+				requested = r.URL.Path
+			}
+			body := ioutil.NopCloser(bytes.NewReader([]byte(data)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	r, err := NewClient("apikey", WithHTTPClient(mc)).NewListResolver()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := r.GetBestSellersListOn(date(t, "2015-07-04"), "Hardcover Fiction", nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if want := "/svc/books/v3/lists/2015-06-28/hardcover-fiction.json"; requested != want {
+		t.Errorf("got request %v, want %v", requested, want)
+	}
+}
@@ -18,6 +18,9 @@ const (
 	// OverviewEndpoint is the endpoint used to Get top 5 books for all the Best Sellers lists for specified date.
 	OverviewEndpoint = "/lists/overview.json"
 
+	// FullOverviewEndpoint is the endpoint used to Get all books for all the Best Sellers lists for specified date.
+	FullOverviewEndpoint = "/lists/full-overview.json"
+
 	// ReviewsEndpoint is the endpoint for Getting book reviews.
 	ReviewsEndpoint = "/reviews.json"
 )
\ No newline at end of file
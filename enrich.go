@@ -0,0 +1,130 @@
+package books
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EnrichOptions configures the review lookups made by the enrichment helpers
+type EnrichOptions struct {
+	// Workers is the number of lookups in flight at once, 4 if zero
+	Workers int
+	// Interval is the minimum time between two lookups, no limit if zero
+	Interval time.Duration
+}
+
+// BookReviews holds the NYT reviews of a book found on a list
+type BookReviews struct {
+	ListName string
+	Rank     int
+	Title    string
+	Author   string
+	ISBN13   string
+	Reviews  []Review
+	Err      error
+}
+
+// Reviewed reports whether the book was reviewed in the Times
+func (br BookReviews) Reviewed() bool {
+	return len(br.Reviews) > 0
+}
+
+// EnrichList looks up the reviews of every book of a list by ISBN13.
+// Results follow the order of the list's books; if any lookup failed,
+// a *BatchError is returned along with them.
+func (c *Client) EnrichList(ctx context.Context, list *ListByDate, opts EnrichOptions) ([]BookReviews, error) {
+	var out []BookReviews
+	for _, b := range list.Results.Books {
+		out = append(out, BookReviews{
+			ListName: list.Results.ListName,
+			Rank:     b.Rank,
+			Title:    b.Title,
+			Author:   b.Author,
+			ISBN13:   b.PrimaryISBN13,
+		})
+	}
+
+	return c.enrich(ctx, out, opts)
+}
+
+// EnrichOverview is EnrichList for every book of every list of an overview.
+// A book appearing on several lists is only looked up once.
+func (c *Client) EnrichOverview(ctx context.Context, o *Overview, opts EnrichOptions) ([]BookReviews, error) {
+	var out []BookReviews
+	for _, l := range o.Results.Lists {
+		for _, b := range l.Books {
+			out = append(out, BookReviews{
+				ListName: l.ListName,
+				Rank:     b.Rank,
+				Title:    b.Title,
+				Author:   b.Author,
+				ISBN13:   b.PrimaryISBN13,
+			})
+		}
+	}
+
+	return c.enrich(ctx, out, opts)
+}
+
+func (c *Client) enrich(ctx context.Context, entries []BookReviews, opts EnrichOptions) ([]BookReviews, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	positions := make(map[string][]int)
+	var isbns []string
+	for i, b := range entries {
+		if b.ISBN13 == "" {
+			continue
+		}
+		if _, ok := positions[b.ISBN13]; !ok {
+			isbns = append(isbns, b.ISBN13)
+		}
+		positions[b.ISBN13] = append(positions[b.ISBN13], i)
+	}
+
+	lim := newLimiter(opts.Interval)
+	defer lim.stop()
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for isbn := range jobs {
+				var reviews *Reviews
+				err := lim.wait(ctx)
+				if err == nil {
+					reviews, err = c.getReviews(ctx, QueryParam{"isbn": isbn})
+				}
+				for _, pos := range positions[isbn] {
+					entries[pos].Err = err
+					if reviews != nil {
+						entries[pos].Reviews = reviews.Results
+					}
+				}
+			}
+		}()
+	}
+
+	for _, isbn := range isbns {
+		jobs <- isbn
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for _, b := range entries {
+		if b.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return entries, &BatchError{Failed: failed, Total: len(entries)}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package books
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestGetReviewsBy(t *testing.T) {
+	var got string
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			got = r.URL.RawQuery
+			body := ioutil.NopCloser(bytes.NewReader([]byte(`{"status": "OK"}`)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+
+	tests := []struct {
+		name string
+		get  func() (*Reviews, error)
+		want string
+	}{
+		{"isbn", func() (*Reviews, error) { return c.GetReviewsByISBN("9780307476463") }, "api-key=apikey&isbn=9780307476463"},
+		{"title", func() (*Reviews, error) { return c.GetReviewsByTitle("1Q84") }, "api-key=apikey&title=1Q84"},
+		{"author", func() (*Reviews, error) { return c.GetReviewsByAuthor("Haruki Murakami") }, "api-key=apikey&author=Haruki+Murakami"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.get(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got query %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichOverview(t *testing.T) {
+	overviewJSON := `{"results": {"lists": [
+		{"list_name": "Combined Print and E-Book Fiction", "books": [{"rank": 1, "title": "1Q84", "primary_isbn13": "9780307476463"}, {"rank": 2, "title": "THE GANGSTER", "primary_isbn13": "9780698406421"}]},
+		{"list_name": "Hardcover Fiction", "books": [{"rank": 3, "title": "1Q84", "primary_isbn13": "9780307476463"}]}
+	]}}`
+	var o Overview
+	if err := json.Unmarshal([]byte(overviewJSON), &o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			isbn := r.URL.Query().Get("isbn")
+			mu.Lock()
+			calls[isbn]++
+			mu.Unlock()
+			data := `{"status": "OK", "num_results": 0, "results": []}`
+			if isbn == "9780307476463" {
+				data = `{"status": "OK", "num_results": 1, "results": [{"url": "http://www.nytimes.com/2011/11/10/books/1q84-by-haruki-murakami-review.html", "isbn13": ["9780307476463"]}]}`
+			}
+			body := ioutil.NopCloser(bytes.NewReader([]byte(data)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+
+	got, err := c.EnrichOverview(context.Background(), &o, EnrichOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d books, want 3", len(got))
+	}
+	if !got[0].Reviewed() || got[1].Reviewed() || !got[2].Reviewed() {
+		t.Errorf("unexpected reviews %+v", got)
+	}
+	if got[2].ListName != "Hardcover Fiction" || got[2].Rank != 3 {
+		t.Errorf("got %+v, want the hardcover fiction entry", got[2])
+	}
+	if calls["9780307476463"] != 1 {
+		t.Errorf("got %d lookups for a book on two lists, want 1", calls["9780307476463"])
+	}
+}
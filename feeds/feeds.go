@@ -0,0 +1,326 @@
+// Package feeds turns Books API responses into RSS 2.0 and Atom feeds
+package feeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// Feed is the format-neutral representation of a feed,
+// rendered with WriteRSS or WriteAtom
+type Feed struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is a single entry of a Feed
+type Item struct {
+	// GUID stays the same for as long as the entry is unchanged
+	// so that feed readers don't show it twice
+	GUID        string
+	Title       string
+	Link        string
+	Author      string
+	Description string
+	Image       string
+	Published   time.Time
+}
+
+// FromList builds a Feed from a best sellers list, one item per book.
+// link is used as the feed link, and may be empty.
+func FromList(list *books.ListByDate, link string) *Feed {
+	res := list.Results
+	published := parseDate(res.PublishedDate)
+	updated := parseTimestamp(list.LastModified)
+	if updated.IsZero() {
+		updated = published
+	}
+
+	f := &Feed{
+		ID:          fmt.Sprintf("tag:nytimes.com,2008:books/lists/%s", encodeName(res.ListName)),
+		Title:       fmt.Sprintf("NYT Best Sellers: %s", res.DisplayName),
+		Link:        link,
+		Description: fmt.Sprintf("%s best sellers list published %s", res.DisplayName, res.PublishedDate),
+		Updated:     updated,
+	}
+
+	for _, b := range res.Books {
+		var desc strings.Builder
+		fmt.Fprintf(&desc, "<p>#%d", b.Rank)
+		switch {
+		case b.WeeksOnList <= 1:
+			desc.WriteString(" &middot; new this week")
+		default:
+			fmt.Fprintf(&desc, " &middot; %d weeks on the list", b.WeeksOnList)
+		}
+		desc.WriteString("</p>")
+		if b.BookImage != "" {
+			fmt.Fprintf(&desc, `<p><img src="%s" alt="%s"></p>`, html.EscapeString(b.BookImage), html.EscapeString(b.Title))
+		}
+		if b.Description != "" {
+			fmt.Fprintf(&desc, "<p>%s</p>", html.EscapeString(b.Description))
+		}
+		if b.AmazonProductURL != "" {
+			fmt.Fprintf(&desc, `<p><a href="%s">Buy on Amazon</a></p>`, html.EscapeString(b.AmazonProductURL))
+		}
+
+		f.Items = append(f.Items, Item{
+			GUID:        bookGUID(res.ListName, b),
+			Title:       fmt.Sprintf("#%d %s by %s", b.Rank, b.Title, b.Author),
+			Link:        b.AmazonProductURL,
+			Author:      b.Author,
+			Description: desc.String(),
+			Image:       b.BookImage,
+			Published:   published,
+		})
+	}
+
+	return f
+}
+
+// bookGUID identifies a book on a list across editions, by its ISBN
+// or, lacking one, by its title and author
+func bookGUID(listName string, b books.ListBook) string {
+	id := b.PrimaryISBN13
+	if id == "" {
+		id = b.PrimaryISBN10
+	}
+	if id == "" {
+		id = encodeName(b.Title + " by " + b.Author)
+	}
+
+	return fmt.Sprintf("tag:nytimes.com,2008:books/lists/%s/%s", encodeName(listName), id)
+}
+
+// FromReviews builds a Feed from book reviews, one item per review.
+// The review URL is used as the GUID.
+func FromReviews(reviews *books.Reviews, title, link string) *Feed {
+	f := &Feed{
+		ID:          "tag:nytimes.com,2008:books/reviews/" + encodeName(title),
+		Title:       title,
+		Link:        link,
+		Description: title,
+	}
+
+	for _, r := range reviews.Results {
+		published := parseDate(r.PublicationDt)
+		if published.After(f.Updated) {
+			f.Updated = published
+		}
+		f.Items = append(f.Items, Item{
+			GUID:        r.URL,
+			Title:       fmt.Sprintf("%s by %s", r.BookTitle, r.BookAuthor),
+			Link:        r.URL,
+			Author:      r.ByLine,
+			Description: html.EscapeString(r.Summary),
+			Published:   published,
+		})
+	}
+
+	return f
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int    `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	Description string        `xml:"description"`
+	Author      string        `xml:"dc:creator,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+// WriteRSS writes the feed to w as an RSS 2.0 document
+func (f *Feed) WriteRSS(w io.Writer) error {
+	doc := rss{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			LastBuildDate: rssDate(f.Updated),
+		},
+	}
+	for _, it := range f.Items {
+		item := rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			Description: it.Description,
+			Author:      it.Author,
+			GUID:        rssGUID{IsPermaLink: strings.HasPrefix(it.GUID, "http"), Value: it.GUID},
+			PubDate:     rssDate(it.Published),
+		}
+		if it.Image != "" {
+			item.Enclosure = &rssEnclosure{URL: it.Image, Type: imageType(it.Image)}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	return encode(w, doc)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomPerson `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Content atomText    `xml:"content"`
+}
+
+// WriteAtom writes the feed to w as an Atom document
+func (f *Feed) WriteAtom(w io.Writer) error {
+	doc := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: atomDate(f.Updated),
+	}
+	if f.Link != "" {
+		doc.Links = append(doc.Links, atomLink{Href: f.Link, Rel: "alternate"})
+	}
+	for _, it := range f.Items {
+		entry := atomEntry{
+			ID:      it.GUID,
+			Title:   it.Title,
+			Updated: atomDate(it.Published),
+			Content: atomText{Type: "html", Value: it.Description},
+		}
+		if it.Author != "" {
+			entry.Author = &atomPerson{Name: it.Author}
+		}
+		if it.Link != "" {
+			entry.Links = append(entry.Links, atomLink{Href: it.Link, Rel: "alternate"})
+		}
+		if it.Image != "" {
+			entry.Links = append(entry.Links, atomLink{Href: it.Image, Rel: "enclosure", Type: imageType(it.Image)})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return encode(w, doc)
+}
+
+func encode(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+func parseDate(s string) time.Time {
+	t, err := time.Parse(books.DateLayout, s)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+func parseTimestamp(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+func rssDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC1123Z)
+}
+
+func atomDate(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func imageType(link string) string {
+	switch {
+	case strings.HasSuffix(link, ".png"):
+		return "image/png"
+	case strings.HasSuffix(link, ".gif"):
+		return "image/gif"
+	default:
+		return "image/jpeg"
+	}
+}
+
+// encodeName turns a display or list name into the hyphenated
+// form used by the API, e.g. "Hardcover Fiction" -> "hardcover-fiction"
+func encodeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
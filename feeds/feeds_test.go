@@ -0,0 +1,120 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+const listJSON = `{"status": "OK", "num_results": 1, "last_modified": "2015-12-25T13:05:20-05:00", "results": {"list_name": "Trade Fiction Paperback", "bestsellers_date": "2015-12-19", "published_date": "2016-01-03", "display_name": "Paperback Trade Fiction", "updated": "WEEKLY", "books": [{"rank": 1, "weeks_on_list": 60, "primary_isbn10": "0553418025", "primary_isbn13": "9780553418026", "publisher": "Broadway", "description": "Separated from his crew, an astronaut <embarks> on a quest to stay alive on Mars.", "title": "THE MARTIAN", "author": "Andy Weir", "book_image": "http://du.ec2.nytimes.com.s3.amazonaws.com/prd/books/9780804139038.jpg", "amazon_product_url": "http://www.amazon.com/dp/B00EMXBDMA"}]}}`
+
+const reviewsJSON = `{"status": "OK", "num_results": 1, "results": [{"url": "http://www.nytimes.com/2011/11/10/books/1q84-by-haruki-murakami-review.html", "publication_dt": "2011-11-10", "byline": "JANET MASLIN", "book_title": "1Q84", "book_author": "Haruki Murakami", "summary": "A Tokyo with two moons.", "isbn13": ["9780307476463"]}]}`
+
+func TestFromList(t *testing.T) {
+	var list books.ListByDate
+	if err := json.Unmarshal([]byte(listJSON), &list); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := FromList(&list, "")
+	if len(f.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(f.Items))
+	}
+
+	got := f.Items[0].GUID
+	want := "tag:nytimes.com,2008:books/lists/trade-fiction-paperback/9780553418026"
+	if got != want {
+		t.Errorf("got GUID %v, want %v", got, want)
+	}
+
+	// the same book must keep its GUID from one edition to the next
+	next := list
+	next.Results.PublishedDate = "2016-01-10"
+	next.Results.Books = append([]books.ListBook(nil), list.Results.Books...)
+	next.Results.Books[0].Rank = 2
+	if again := FromList(&next, "").Items[0].GUID; again != got {
+		t.Errorf("GUID not stable: %v != %v", again, got)
+	}
+
+	t.Run("rss", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := f.WriteRSS(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var doc rss
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid xml: %v", err)
+		}
+		item := doc.Channel.Items[0]
+		if item.Title != "#1 THE MARTIAN by Andy Weir" {
+			t.Errorf("got title %v", item.Title)
+		}
+		if item.Enclosure == nil || item.Enclosure.URL != list.Results.Books[0].BookImage {
+			t.Errorf("got enclosure %v, want book image", item.Enclosure)
+		}
+		if !strings.Contains(item.Description, "60 weeks on the list") {
+			t.Errorf("description missing weeks on list: %v", item.Description)
+		}
+		if !strings.Contains(item.Description, "&lt;embarks&gt;") {
+			t.Errorf("description not escaped: %v", item.Description)
+		}
+	})
+
+	t.Run("atom", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := f.WriteAtom(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var doc atomFeed
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid xml: %v", err)
+		}
+		if doc.Updated != "2015-12-25T18:05:20Z" {
+			t.Errorf("got updated %v", doc.Updated)
+		}
+		if doc.Entries[0].ID != want {
+			t.Errorf("got id %v, want %v", doc.Entries[0].ID, want)
+		}
+	})
+}
+
+func TestBookGUIDWithoutISBN(t *testing.T) {
+	a := books.ListBook{Title: "THE MARTIAN", Author: "Andy Weir"}
+	b := books.ListBook{Title: "ARTEMIS", Author: "Andy Weir"}
+	got := bookGUID("Trade Fiction Paperback", a)
+	if want := "tag:nytimes.com,2008:books/lists/trade-fiction-paperback/the-martian-by-andy-weir"; got != want {
+		t.Errorf("got GUID %v, want %v", got, want)
+	}
+	if got == bookGUID("Trade Fiction Paperback", b) {
+		t.Errorf("books without ISBNs share the GUID %v", got)
+	}
+}
+
+func TestFromReviews(t *testing.T) {
+	var reviews books.Reviews
+	if err := json.Unmarshal([]byte(reviewsJSON), &reviews); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := FromReviews(&reviews, "Reviews of Haruki Murakami", "")
+	if got, want := f.Items[0].GUID, reviews.Results[0].URL; got != want {
+		t.Errorf("got GUID %v, want %v", got, want)
+	}
+	if got, want := f.Updated.Format(books.DateLayout), "2011-11-10"; got != want {
+		t.Errorf("got updated %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := f.WriteRSS(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<guid isPermaLink="true">`) {
+		t.Errorf("review GUID should be a permalink:\n%s", buf.String())
+	}
+}
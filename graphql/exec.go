@@ -0,0 +1,556 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// object is an object type of the schema
+type object struct {
+	name   string
+	fields map[string]*fieldDef
+}
+
+// fieldDef is a field of an object type. typ is a type reference such
+// as "String", "[Book]" or "Int!". resolve gets the parent object's value.
+type fieldDef struct {
+	typ     string
+	args    []argDef
+	resolve func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+}
+
+type argDef struct {
+	name     string
+	typ      string
+	fallback interface{}
+}
+
+type schema struct {
+	query *object
+	types map[string]*object
+}
+
+// namedType strips a type reference down to its name
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+func isList(typ string) bool {
+	return strings.HasPrefix(typ, "[")
+}
+
+// Error is an error of a GraphQL response
+type Error struct {
+	Message string `json:"message"`
+	// Path is the path of the field that failed, keys and list indexes
+	Path []interface{} `json:"path,omitempty"`
+}
+
+// Response is the result of a query
+type Response struct {
+	Data   interface{} `json:"data"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// orderedMap is a JSON object keeping the order of the query's fields
+type orderedMap struct {
+	keys []string
+	vals map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, v interface{}) {
+	if _, ok := m.vals[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.vals[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		val, err := json.Marshal(m.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+type executor struct {
+	schema *schema
+	doc    *document
+	vars   map[string]interface{}
+
+	mu     sync.Mutex
+	errors []Error
+}
+
+func (e *executor) fail(path []interface{}, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, Error{Message: message(err), Path: append([]interface{}(nil), path...)})
+}
+
+// execute runs a query against s
+func execute(ctx context.Context, s *schema, query string, variables map[string]interface{}, operationName string) *Response {
+	if len(query) > MaxQueryLength {
+		return &Response{Errors: []Error{{Message: fmt.Sprintf("graphql: query longer than %d bytes", MaxQueryLength)}}}
+	}
+	doc, err := parse(query)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	op, err := pickOperation(doc, operationName)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	vars, err := coerceVariables(op, variables)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{schema: s, doc: doc, vars: vars}
+	if errs := e.validate(op); len(errs) > 0 {
+		return &Response{Errors: errs}
+	}
+	data := e.selectionSet(ctx, s.query, nil, op.selection, nil)
+
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func pickOperation(doc *document, name string) (*operation, error) {
+	var op *operation
+	switch {
+	case name != "":
+		for _, o := range doc.operations {
+			if o.name == name {
+				op = o
+			}
+		}
+		if op == nil {
+			return nil, fmt.Errorf("graphql: unknown operation %q", name)
+		}
+	case len(doc.operations) > 1:
+		return nil, fmt.Errorf("graphql: operationName is required for documents with several operations")
+	default:
+		op = doc.operations[0]
+	}
+	if op.kind != "query" {
+		return nil, fmt.Errorf("graphql: %s operations aren't supported", op.kind)
+	}
+
+	return op, nil
+}
+
+func coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	for _, def := range op.variables {
+		v, ok := given[def.name]
+		if !ok {
+			if def.fallback == nil && def.nonNull {
+				return nil, fmt.Errorf("graphql: variable $%s is required", def.name)
+			}
+			v = def.fallback
+		}
+		if v == nil && def.nonNull {
+			return nil, fmt.Errorf("graphql: variable $%s can't be null", def.name)
+		}
+		c, err := coerce(v, def.typ)
+		if err != nil {
+			return nil, fmt.Errorf("graphql: variable $%s: %v", def.name, err)
+		}
+		vars[def.name] = c
+	}
+
+	return vars, nil
+}
+
+// coerce converts an input value, from JSON or a literal, to typ
+func coerce(v interface{}, typ string) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch namedType(typ) {
+	case "Int":
+		switch n := v.(type) {
+		case int:
+			return n, nil
+		case float64:
+			if n == float64(int(n)) {
+				return int(n), nil
+			}
+		}
+	case "Float":
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case "String", "ID":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	default:
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("%v isn't a valid %s", v, namedType(typ))
+}
+
+// resolveValue replaces the variables of a literal with their values
+func (e *executor) resolveValue(v value) interface{} {
+	switch v := v.(type) {
+	case variable:
+		return e.vars[string(v)]
+	case enum:
+		return string(v)
+	case []value:
+		list := make([]interface{}, len(v))
+		for i, x := range v {
+			list[i] = e.resolveValue(x)
+		}
+		return list
+	case objectValue:
+		obj := make(map[string]interface{}, len(v))
+		for _, arg := range v {
+			obj[arg.name] = e.resolveValue(arg.val)
+		}
+		return obj
+	}
+
+	return v
+}
+
+func (e *executor) arguments(def *fieldDef, args []argument) (map[string]interface{}, error) {
+	given := make(map[string]interface{}, len(args))
+	for _, a := range args {
+		given[a.name] = e.resolveValue(a.val)
+	}
+
+	out := make(map[string]interface{}, len(def.args))
+	for _, a := range def.args {
+		v, ok := given[a.name]
+		if !ok || v == nil {
+			v = a.fallback
+		}
+		if v == nil && strings.HasSuffix(a.typ, "!") {
+			return nil, fmt.Errorf("argument %s is required", a.name)
+		}
+		c, err := coerce(v, a.typ)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %v", a.name, err)
+		}
+		out[a.name] = c
+	}
+
+	return out, nil
+}
+
+// included evaluates the skip and include directives
+func (e *executor) included(ds []directive) bool {
+	for _, d := range ds {
+		for _, a := range d.args {
+			if a.name != "if" {
+				continue
+			}
+			cond, _ := e.resolveValue(a.val).(bool)
+			if d.name == "skip" && cond || d.name == "include" && !cond {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// collect flattens fragments into the fields of a selection set,
+// grouped by response key in query order
+func (e *executor) collect(obj *object, sel []selection, keys *[]string, fields map[string][]*field, visited map[string]bool) {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *field:
+			if !e.included(s.directives) {
+				continue
+			}
+			k := s.key()
+			if _, ok := fields[k]; !ok {
+				*keys = append(*keys, k)
+			}
+			fields[k] = append(fields[k], s)
+		case *fragmentSpread:
+			f := e.doc.fragments[s.name]
+			if f == nil || visited[s.name] || !e.included(s.directives) || f.on != obj.name {
+				continue
+			}
+			visited[s.name] = true
+			e.collect(obj, f.selection, keys, fields, visited)
+		case *inlineFragment:
+			if !e.included(s.directives) || s.on != "" && s.on != obj.name {
+				continue
+			}
+			e.collect(obj, s.selection, keys, fields, visited)
+		}
+	}
+}
+
+func (e *executor) selectionSet(ctx context.Context, obj *object, source interface{}, sel []selection, path []interface{}) *orderedMap {
+	var keys []string
+	fields := make(map[string][]*field)
+	e.collect(obj, sel, &keys, fields, make(map[string]bool))
+
+	out := &orderedMap{vals: make(map[string]interface{}, len(keys))}
+	for _, k := range keys {
+		fs := fields[k]
+		f := fs[0]
+		fieldPath := append(path[:len(path):len(path)], k)
+		if f.name == "__typename" {
+			out.set(k, obj.name)
+			continue
+		}
+
+		def := obj.fields[f.name]
+		args, err := e.arguments(def, f.args)
+		if err != nil {
+			e.fail(fieldPath, err)
+			out.set(k, nil)
+			continue
+		}
+		v, err := def.resolve(ctx, source, args)
+		if err != nil {
+			e.fail(fieldPath, err)
+			out.set(k, nil)
+			continue
+		}
+
+		var sub []selection
+		for _, f := range fs {
+			sub = append(sub, f.selection...)
+		}
+		out.set(k, e.complete(ctx, def.typ, v, sub, fieldPath))
+	}
+
+	return out
+}
+
+// complete turns a resolved value into its response, running the
+// elements of lists of objects concurrently so that their fields'
+// calls to the API can be batched
+func (e *executor) complete(ctx context.Context, typ string, v interface{}, sel []selection, path []interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if v == nil || (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return nil
+	}
+
+	obj := e.schema.types[namedType(typ)]
+	if !isList(typ) {
+		if obj == nil {
+			return v
+		}
+		return e.selectionSet(ctx, obj, v, sel, path)
+	}
+
+	inner := strings.TrimSuffix(typ, "!")
+	inner = inner[1 : len(inner)-1]
+	out := make([]interface{}, rv.Len())
+	if obj == nil {
+		for i := range out {
+			out[i] = rv.Index(i).Interface()
+		}
+		return out
+	}
+
+	var wg sync.WaitGroup
+	for i := range out {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			itemPath := append(path[:len(path):len(path)], i)
+			out[i] = e.complete(ctx, inner, rv.Index(i).Interface(), sel, itemPath)
+		}(i)
+	}
+	wg.Wait()
+
+	return out
+}
+
+// maxQueryDepth is how deeply a query's selections may nest,
+// fragments included
+const maxQueryDepth = 32
+
+// validate checks the fields and arguments of an operation
+// against the schema before it is executed. Each fragment is
+// checked once, however many times it is spread.
+func (e *executor) validate(op *operation) []Error {
+	if err := e.fragmentCycle(); err != nil {
+		return []Error{{Message: err.Error()}}
+	}
+
+	var errs []Error
+	// heights holds how deeply the fragments checked nest
+	heights := make(map[string]int)
+	// walk checks sel, depth levels down, and returns how deeply it nests
+	var walk func(obj *object, sel []selection, path string, depth int) int
+	walk = func(obj *object, sel []selection, path string, depth int) int {
+		if depth > maxQueryDepth {
+			errs = append(errs, Error{Message: "graphql: query is too deep at " + path})
+			return 0
+		}
+		height := 0
+		nest := func(h int) {
+			if h > height {
+				height = h
+			}
+		}
+		for _, s := range sel {
+			switch s := s.(type) {
+			case *field:
+				p := path + "." + s.key()
+				if s.name == "__typename" {
+					continue
+				}
+				def, ok := obj.fields[s.name]
+				if !ok {
+					errs = append(errs, Error{Message: fmt.Sprintf("graphql: %s has no field %q", obj.name, s.name)})
+					continue
+				}
+				for _, a := range s.args {
+					if !hasArg(def, a.name) {
+						errs = append(errs, Error{Message: fmt.Sprintf("graphql: %s.%s has no argument %q", obj.name, s.name, a.name)})
+					}
+				}
+				child := e.schema.types[namedType(def.typ)]
+				switch {
+				case child != nil && len(s.selection) == 0:
+					errs = append(errs, Error{Message: fmt.Sprintf("graphql: %s of type %s needs a selection of fields", p[1:], def.typ)})
+				case child == nil && len(s.selection) > 0:
+					errs = append(errs, Error{Message: fmt.Sprintf("graphql: %s of type %s can't have a selection", p[1:], def.typ)})
+				case child != nil:
+					nest(1 + walk(child, s.selection, p, depth+1))
+				}
+			case *fragmentSpread:
+				f, ok := e.doc.fragments[s.name]
+				if !ok {
+					errs = append(errs, Error{Message: fmt.Sprintf("graphql: unknown fragment %q", s.name)})
+					continue
+				}
+				h, ok := heights[s.name]
+				if !ok {
+					t, ok := e.schema.types[f.on]
+					if !ok {
+						errs = append(errs, Error{Message: fmt.Sprintf("graphql: unknown type %q", f.on)})
+					} else {
+						h = walk(t, f.selection, path, 0)
+					}
+					heights[s.name] = h
+				}
+				if depth+1+h > maxQueryDepth {
+					errs = append(errs, Error{Message: "graphql: query is too deep at " + path})
+				}
+				nest(1 + h)
+			case *inlineFragment:
+				t := obj
+				if s.on != "" {
+					if t = e.schema.types[s.on]; t == nil {
+						errs = append(errs, Error{Message: fmt.Sprintf("graphql: unknown type %q", s.on)})
+						continue
+					}
+				}
+				nest(1 + walk(t, s.selection, path, depth+1))
+			}
+		}
+
+		return height
+	}
+	walk(e.schema.query, op.selection, "", 0)
+
+	return errs
+}
+
+// fragmentCycle returns an error if a fragment spreads itself,
+// directly or through other fragments
+func (e *executor) fragmentCycle() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(name string) error
+	visit = func(name string) error {
+		f, ok := e.doc.fragments[name]
+		if !ok || state[name] == done {
+			return nil
+		}
+		if state[name] == visiting {
+			return fmt.Errorf("graphql: fragment %q spreads itself", name)
+		}
+		state[name] = visiting
+		for _, spread := range spreads(f.selection, nil) {
+			if err := visit(spread); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+
+		return nil
+	}
+
+	names := make([]string, 0, len(e.doc.fragments))
+	for name := range e.doc.fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// spreads appends the names of the fragments spread in sel to names
+func spreads(sel []selection, names []string) []string {
+	for _, s := range sel {
+		switch s := s.(type) {
+		case *field:
+			names = spreads(s.selection, names)
+		case *fragmentSpread:
+			names = append(names, s.name)
+		case *inlineFragment:
+			names = spreads(s.selection, names)
+		}
+	}
+
+	return names
+}
+
+func hasArg(def *fieldDef, name string) bool {
+	for _, a := range def.args {
+		if a.name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+// Package graphql serves the Books API through GraphQL, without
+// depending on a GraphQL library. The schema has the types List, Book,
+// RankHistory and Review, and Handler.Schema prints it in full:
+//
+//	c := books.NewClient(apiKey, books.WithStatusErrors())
+//	http.Handle("/graphql", graphql.NewHandler(c))
+//
+// The client needs WithStatusErrors for the errors of the API, such as
+// a used up quota, to be reported as such rather than as empty results.
+//
+// A query such as
+//
+//	{ list(name: "hardcover-fiction") { books { title rankHistory { list rank } } } }
+//
+// would cost a call per book if resolved naively. Instead, API calls go
+// through a cache shared by all queries, concurrent identical calls are
+// made once, and a query making more calls than WithMaxCalls allows
+// fails those fields rather than eating up the API quota.
+//
+// Only queries are supported: there is no introspection, and
+// mutations and subscriptions are rejected. Queries are limited to
+// MaxQueryLength bytes and a few dozen levels of nesting, and errors of
+// the API are reported by what they mean, never word for word.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// MaxQueryLength is the longest query executed, in bytes
+const MaxQueryLength = 64 << 10
+
+// maxBody is the largest request body read, leaving room for variables
+const maxBody = 1 << 20
+
+// Source is what the Handler resolves queries with, implemented by *books.Client
+type Source interface {
+	GetBestSellersListNames() (*books.Names, error)
+	GetBestSellersListByDate(date, listName string, qp books.QueryParam) (*books.ListByDate, error)
+	GetBestSellersListHistory(qp books.QueryParam) (*books.ListHistory, error)
+	GetReviews(qp books.QueryParam) (*books.Reviews, error)
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithTTL sets how long API responses are cached, an hour by default
+func WithTTL(ttl time.Duration) Option {
+	return func(h *Handler) {
+		h.loader.ttl = ttl
+	}
+}
+
+// WithMaxCalls sets the number of uncached API calls a single query
+// may make, 50 by default. Zero or less means no limit.
+func WithMaxCalls(n int) Option {
+	return func(h *Handler) {
+		h.maxCalls = n
+	}
+}
+
+// WithConcurrency sets the number of API calls made at once, 4 by default
+func WithConcurrency(n int) Option {
+	return func(h *Handler) {
+		if n < 1 {
+			n = 1
+		}
+		h.loader.sem = make(chan struct{}, n)
+	}
+}
+
+// Request is a GraphQL request, as posted in JSON
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// Handler executes GraphQL queries against a Source
+type Handler struct {
+	source   Source
+	schema   *schema
+	loader   *loader
+	maxCalls int
+}
+
+// NewHandler constructs a Handler resolving queries with source
+func NewHandler(source Source, options ...Option) *Handler {
+	h := &Handler{
+		source:   source,
+		loader:   newLoader(time.Hour, 4),
+		maxCalls: 50,
+	}
+	h.schema = newSchema(h)
+	for _, option := range options {
+		option(h)
+	}
+
+	return h
+}
+
+// Schema returns the schema in the GraphQL schema definition language
+func (h *Handler) Schema() string {
+	return h.schema.sdl()
+}
+
+// Execute runs a query. Errors are reported in the Response: a
+// field that failed is null and has an error with its path.
+func (h *Handler) Execute(ctx context.Context, req Request) *Response {
+	ctx = withBudget(ctx, h.maxCalls)
+	return execute(ctx, h.schema, req.Query, req.Variables, req.OperationName)
+}
+
+// ServeHTTP executes queries given in the query string of GET requests,
+// as "query", "variables" and "operationName", or posted as JSON
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "graphql: invalid variables: "+err.Error())
+				return
+			}
+		}
+	case http.MethodPost:
+		body := http.MaxBytesReader(w, r.Body, maxBody)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "graphql: invalid request: "+err.Error())
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, "graphql: method not allowed")
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "graphql: missing query")
+		return
+	}
+
+	resp := h.Execute(r.Context(), req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		// the query didn't run at all
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, resp)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, &Response{Errors: []Error{{Message: msg}}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
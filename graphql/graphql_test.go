@@ -0,0 +1,439 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+type fakeSource struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	names   *books.Names
+	list    *books.ListByDate
+	history *books.ListHistory
+	reviews *books.Reviews
+	err     error
+}
+
+func (s *fakeSource) called(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[key]++
+}
+
+func (s *fakeSource) total() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, c := range s.calls {
+		n += c
+	}
+
+	return n
+}
+
+func (s *fakeSource) GetBestSellersListNames() (*books.Names, error) {
+	s.called("names")
+	return s.names, s.err
+}
+
+func (s *fakeSource) GetBestSellersListByDate(date, listName string, qp books.QueryParam) (*books.ListByDate, error) {
+	s.called("list " + date + " " + listName + " " + qp.String())
+	return s.list, s.err
+}
+
+func (s *fakeSource) GetBestSellersListHistory(qp books.QueryParam) (*books.ListHistory, error) {
+	s.called("history " + qp.String())
+	return s.history, s.err
+}
+
+func (s *fakeSource) GetReviews(qp books.QueryParam) (*books.Reviews, error) {
+	s.called("reviews " + qp.String())
+	return s.reviews, s.err
+}
+
+// newSource loads the testdata, with the list's book repeated under
+// three ISBNs, two of the books sharing one
+func newSource(t *testing.T) *fakeSource {
+	s := &fakeSource{calls: make(map[string]int)}
+	for name, v := range map[string]interface{}{
+		"names.json":        &s.names,
+		"list_by_date.json": &s.list,
+		"history.json":      &s.history,
+		"reviews.json":      &s.reviews,
+	} {
+		data, err := ioutil.ReadFile("../testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b := s.list.Results.Books[0]
+	s.list.Results.Books = nil
+	for i, isbn := range []string{"9780000000001", "9780000000002", "9780000000001"} {
+		b.Rank, b.PrimaryISBN13 = i+1, isbn
+		s.list.Results.Books = append(s.list.Results.Books, b)
+	}
+
+	return s
+}
+
+// run executes a query and returns its response as decoded JSON
+func run(t *testing.T, h *Handler, query string, vars map[string]interface{}) (map[string]interface{}, []Error) {
+	t.Helper()
+	resp := h.Execute(context.Background(), Request{Query: query, Variables: vars})
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	return out, resp.Errors
+}
+
+func TestList(t *testing.T) {
+	src := newSource(t)
+	h := NewHandler(src)
+	data, errs := run(t, h, `query Q($name: String!) {
+		list(name: $name, date: "2016-01-03") {
+			__typename
+			displayName
+			top: books { rank title ...ids }
+		}
+	}
+	fragment ids on Book { isbn13 }`, map[string]interface{}{"name": "trade-fiction-paperback"})
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	l := data["list"].(map[string]interface{})
+	if l["__typename"] != "List" || l["displayName"] != "Paperback Trade Fiction" {
+		t.Errorf("got %v", l)
+	}
+	top := l["top"].([]interface{})
+	if len(top) != 3 {
+		t.Fatalf("got %d books, want 3", len(top))
+	}
+	want := map[string]interface{}{"rank": 2.0, "title": "THE MARTIAN", "isbn13": "9780000000002"}
+	for k, v := range want {
+		if top[1].(map[string]interface{})[k] != v {
+			t.Errorf("got %s %v, want %v", k, top[1].(map[string]interface{})[k], v)
+		}
+	}
+	if src.calls["list 2016-01-03 trade-fiction-paperback "] != 1 {
+		t.Errorf("got calls %v", src.calls)
+	}
+}
+
+func TestFieldOrder(t *testing.T) {
+	h := NewHandler(newSource(t))
+	resp := h.Execute(context.Background(), Request{Query: `{ list(name: "x") { publishedDate name displayName } }`})
+	got, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":{"list":{"publishedDate":"2016-01-03","name":"Trade Fiction Paperback","displayName":"Paperback Trade Fiction"}}}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestNestedQueriesAreBatched(t *testing.T) {
+	src := newSource(t)
+	h := NewHandler(src)
+	query := `{
+		list(name: "x") {
+			books {
+				rankHistory { list rank }
+				reviews { byline }
+			}
+		}
+		again: list(name: "x") { books { reviews { url } } }
+	}`
+	data, errs := run(t, h, query, nil)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	// one list, and a history and reviews call per distinct ISBN
+	if n := src.total(); n != 5 {
+		t.Errorf("got %d calls, want 5: %v", n, src.calls)
+	}
+	for key, n := range src.calls {
+		if n != 1 {
+			t.Errorf("%s was called %d times", key, n)
+		}
+	}
+	b := data["list"].(map[string]interface{})["books"].([]interface{})[2].(map[string]interface{})
+	ranks := b["rankHistory"].([]interface{})
+	if len(ranks) != 1 || ranks[0].(map[string]interface{})["list"] != "Business Books" {
+		t.Errorf("got rank history %v", ranks)
+	}
+
+	// everything is cached for the next query
+	run(t, h, query, nil)
+	if n := src.total(); n != 5 {
+		t.Errorf("got %d calls after a second query, want 5", n)
+	}
+}
+
+func TestMaxCalls(t *testing.T) {
+	src := newSource(t)
+	h := NewHandler(src, WithMaxCalls(2))
+	data, errs := run(t, h, `{
+		list(name: "x") { name }
+		first: reviews(isbn: "1") { url }
+		again: reviews(isbn: "1") { url }
+		second: reviews(isbn: "2") { url }
+	}`, nil)
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "more than 2 API calls") {
+		t.Fatalf("got errors %v", errs)
+	}
+	if len(errs[0].Path) != 1 || errs[0].Path[0] != "second" {
+		t.Errorf("got path %v", errs[0].Path)
+	}
+	if data["list"] == nil || data["again"] == nil || data["second"] != nil {
+		t.Errorf("got %v, want only the third call to fail", data)
+	}
+	if n := src.total(); n != 2 {
+		t.Errorf("got %d calls, want 2", n)
+	}
+
+	// cached responses don't count
+	_, errs = run(t, h, `{ list(name: "x") { name } reviews(isbn: "1") { url } other: reviews(isbn: "3") { url } }`, nil)
+	if errs != nil {
+		t.Errorf("got errors %v", errs)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	h := NewHandler(newSource(t))
+	data, errs := run(t, h, `{
+		lists { name frequency }
+		book(isbn: "9780399169274") { title author rank rankHistory { displayName } }
+		reviews(author: "Haruki Murakami") { bookTitle isbn13 }
+		skipped: lists @skip(if: true) { name }
+	}`, nil)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	if lists := data["lists"].([]interface{}); len(lists) == 0 || lists[0].(map[string]interface{})["name"] == nil {
+		t.Errorf("got lists %v", lists)
+	}
+	b := data["book"].(map[string]interface{})
+	if b["title"] != "#GIRLBOSS" || b["rank"] != nil || len(b["rankHistory"].([]interface{})) != 1 {
+		t.Errorf("got book %v", b)
+	}
+	r := data["reviews"].([]interface{})[0].(map[string]interface{})
+	if r["bookTitle"] != "1Q84" || r["isbn13"].([]interface{})[0] != "9780307476463" {
+		t.Errorf("got review %v", r)
+	}
+	if _, ok := data["skipped"]; ok {
+		t.Error("got a skipped field")
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	src := newSource(t)
+	src.err = &books.APIError{StatusCode: http.StatusTooManyRequests, Message: "Too Many Requests"}
+	h := NewHandler(src)
+	data, errs := run(t, h, `{ list(name: "x") { name } reviews { url } }`, nil)
+
+	if data["list"] != nil || data["reviews"] != nil {
+		t.Errorf("got %v, want null fields", data)
+	}
+	if len(errs) != 2 || errs[0].Message != "the Books API quota is used up" || errs[1].Message != "one of isbn, title or author is required" {
+		t.Errorf("got errors %v", errs)
+	}
+}
+
+func TestErrorPayload(t *testing.T) {
+	src := newSource(t)
+	h := NewHandler(src)
+
+	// what a client without WithStatusErrors decodes from a 429
+	reviews := src.reviews
+	src.reviews = &books.Reviews{}
+	_, errs := run(t, h, `{ reviews(isbn: "1") { url } }`, nil)
+	if len(errs) != 1 || errs[0].Message != "the Books API answered with an error" {
+		t.Errorf("got errors %v", errs)
+	}
+
+	// and it wasn't cached
+	src.reviews = reviews
+	data, errs := run(t, h, `{ reviews(isbn: "1") { url } }`, nil)
+	if errs != nil || len(data["reviews"].([]interface{})) == 0 {
+		t.Errorf("got %v, %v once the API answers", data, errs)
+	}
+}
+
+func TestUpstreamErrorHidesKey(t *testing.T) {
+	src := newSource(t)
+	src.err = &url.Error{Op: "Get", URL: "https://api.nytimes.com/svc/books/v3/lists/names.json?api-key=SECRETKEY", Err: errors.New("connection refused")}
+	_, errs := run(t, NewHandler(src), `{ lists { name } }`, nil)
+
+	if len(errs) != 1 || errs[0].Message != "the Books API can't be reached" {
+		t.Errorf("got errors %v", errs)
+	}
+}
+
+func TestLoaderBound(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newLoader(time.Minute, 1)
+	l.now = func() time.Time { return now }
+	fetch := func() (interface{}, error) { return 1, nil }
+
+	l.load(context.Background(), "expired", fetch)
+	now = now.Add(time.Second)
+	l.load(context.Background(), "first", fetch)
+	now = now.Add(time.Minute)
+	for i := 0; i < maxCached; i++ {
+		l.load(context.Background(), fmt.Sprint(i), fetch)
+	}
+	if len(l.cache) != maxCached {
+		t.Errorf("got %d cached responses, want %d", len(l.cache), maxCached)
+	}
+	if _, ok := l.cache["expired"]; ok {
+		t.Error("the expired response is still cached")
+	}
+	if _, ok := l.cache["first"]; ok {
+		t.Error("the oldest response is still cached")
+	}
+}
+
+func TestInvalidQueries(t *testing.T) {
+	h := NewHandler(newSource(t))
+	for query, want := range map[string]string{
+		`{ list(name: "x") { nope } }`:                     `List has no field "nope"`,
+		`{ list(name: "x") }`:                              "needs a selection",
+		`{ lists { name { x } } }`:                         "can't have a selection",
+		`{ list(name: "x", year: 2) { name } }`:            `has no argument "year"`,
+		`{ lists { ...missing } }`:                         `unknown fragment "missing"`,
+		`mutation { lists { name } }`:                      "mutation operations aren't supported",
+		`query A { lists { name } } query B { lists }`:     "operationName is required",
+		`query Q($n: String!) { list(name: $n) { name } }`: "variable $n is required",
+		`{ list(name: "x" }`:                               "syntax error",
+	} {
+		resp := h.Execute(context.Background(), Request{Query: query})
+		if resp.Data != nil || len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, want) {
+			t.Errorf("%s: got %+v, want an error containing %q", query, resp, want)
+		}
+	}
+}
+
+func TestFragments(t *testing.T) {
+	h := NewHandler(newSource(t))
+
+	// each fragment spreads the next one twice, which
+	// checked spread by spread would take 2^30 walks
+	var q strings.Builder
+	q.WriteString("{ ...F0 }\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&q, "fragment F%d on Query { ...F%d ...F%d }\n", i, i+1, i+1)
+	}
+	q.WriteString("fragment F30 on Query { lists { name } }")
+	data, errs := run(t, h, q.String(), nil)
+	if errs != nil || data["lists"] == nil {
+		t.Errorf("got %v, %v", data, errs)
+	}
+
+	for query, want := range map[string]string{
+		`{ ...A } fragment A on Query { ...B } fragment B on Query { lists { ...A } }`: `spreads itself`,
+		`{ ...A } fragment A on Query { ...A }`:                                        `fragment "A" spreads itself`,
+	} {
+		resp := h.Execute(context.Background(), Request{Query: query})
+		if resp.Data != nil || len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, want) {
+			t.Errorf("%s: got %+v, want an error containing %q", query, resp, want)
+		}
+	}
+
+	// fragments count towards the depth of a query
+	q.Reset()
+	q.WriteString("{ ...F0 }\n")
+	for i := 0; i < maxQueryDepth; i++ {
+		fmt.Fprintf(&q, "fragment F%d on Query { ...F%d }\n", i, i+1)
+	}
+	fmt.Fprintf(&q, "fragment F%d on Query { lists { name } }", maxQueryDepth)
+	resp := h.Execute(context.Background(), Request{Query: q.String()})
+	if resp.Data != nil || len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, "too deep") {
+		t.Errorf("got %+v, want a query too deep", resp)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	h := NewHandler(newSource(t))
+
+	body := `{"query": "query Q($n: String!) { list(name: $n) { name } }", "variables": {"n": "x"}}`
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/graphql", strings.NewReader(body)))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if want := `{"data":{"list":{"name":"Trade Fiction Paperback"}}}`; strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("got %s, want %s", rec.Body, want)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ lists { nope } }`), nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"data":null`) {
+		t.Errorf("got %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	long := `{"query": "{ lists { name } }` + strings.Repeat(" ", maxBody) + `"}`
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/graphql", strings.NewReader(long)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid request") {
+		t.Errorf("got %d %s for a body too large", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	query := "{ lists { name } }" + strings.Repeat(" ", MaxQueryLength)
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(query), nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "query longer than") {
+		t.Errorf("got %d %s for a query too long", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("DELETE", "/graphql", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got %d", rec.Code)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	sdl := NewHandler(newSource(t)).Schema()
+	for _, want := range []string{
+		"type Query {\n",
+		`  list(name: String!, date: String = "current", offset: Int = 0): List`,
+		"type Book {\n",
+		"  rankHistory: [RankHistory]\n",
+		"type Review {\n",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("schema is missing %q:\n%s", want, sdl)
+		}
+	}
+	if !strings.HasPrefix(sdl, "type Query") {
+		t.Errorf("got schema starting %q", sdl[:20])
+	}
+}
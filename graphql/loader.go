@@ -0,0 +1,201 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/internal/singleflight"
+)
+
+// maxCached is the most responses a loader keeps. Past it, expired
+// responses are dropped, then the ones closest to expiring.
+const maxCached = 1000
+
+// loader fetches from the API by key. Results are cached for a while,
+// concurrent fetches of the same key share one call, and no more than
+// a few calls run at once.
+//
+// The API has no batch endpoints, so what batching there is comes from
+// the executor resolving the elements of a list concurrently: the
+// nested fields of twenty books asking for the same history or reviews
+// end up as a single call.
+type loader struct {
+	ttl time.Duration
+	now func() time.Time
+	sem chan struct{}
+
+	flight singleflight.Group
+	mu     sync.Mutex
+	cache  map[string]cachedValue
+}
+
+type cachedValue struct {
+	val     interface{}
+	expires time.Time
+}
+
+func newLoader(ttl time.Duration, concurrency int) *loader {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &loader{
+		ttl:   ttl,
+		now:   time.Now,
+		sem:   make(chan struct{}, concurrency),
+		cache: make(map[string]cachedValue),
+	}
+}
+
+// budget is the number of calls to the API a query may make
+type budget struct {
+	mu      sync.Mutex
+	max     int
+	charged map[string]bool
+}
+
+type budgetKey struct{}
+
+func withBudget(ctx context.Context, max int) context.Context {
+	return context.WithValue(ctx, budgetKey{}, &budget{max: max, charged: make(map[string]bool)})
+}
+
+// charge counts a call for key against the query's budget,
+// calls for a key the query already paid for being free
+func charge(ctx context.Context, key string) error {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok || b.max <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.charged[key] {
+		return nil
+	}
+	if len(b.charged) >= b.max {
+		return fmt.Errorf("graphql: query needs more than %d API calls", b.max)
+	}
+	b.charged[key] = true
+
+	return nil
+}
+
+func (l *loader) cached(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c, ok := l.cache[key]
+	if !ok || !l.now().Before(c.expires) {
+		return nil, false
+	}
+
+	return c.val, true
+}
+
+// load returns the value for key, calling fetch on a cache miss
+func (l *loader) load(ctx context.Context, key string, fetch func() (interface{}, error)) (interface{}, error) {
+	if v, ok := l.cached(key); ok {
+		return v, nil
+	}
+	if err := charge(ctx, key); err != nil {
+		return nil, err
+	}
+
+	v, err, _ := l.flight.Do(key, func() (interface{}, error) {
+		if v, ok := l.cached(key); ok {
+			return v, nil
+		}
+		l.sem <- struct{}{}
+		defer func() { <-l.sem }()
+
+		v, err := fetch()
+		if err != nil {
+			return nil, &upstreamError{err}
+		}
+		l.mu.Lock()
+		l.store(key, v)
+		l.mu.Unlock()
+		return v, nil
+	})
+
+	return v, err
+}
+
+// store caches v for key, l.mu being held, making room if the cache is full
+func (l *loader) store(key string, v interface{}) {
+	now := l.now()
+	if len(l.cache) >= maxCached {
+		for k, c := range l.cache {
+			if !now.Before(c.expires) {
+				delete(l.cache, k)
+			}
+		}
+	}
+	for len(l.cache) >= maxCached {
+		oldest := ""
+		for k, c := range l.cache {
+			if oldest == "" || c.expires.Before(l.cache[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(l.cache, oldest)
+	}
+	l.cache[key] = cachedValue{val: v, expires: now.Add(l.ttl)}
+}
+
+// upstreamError is an error of the API. Its text can carry the request
+// URL, and with it the api key, so clients are shown what it means instead.
+type upstreamError struct {
+	err error
+}
+
+func (e *upstreamError) Error() string {
+	return e.err.Error()
+}
+
+func (e *upstreamError) Unwrap() error {
+	return e.err
+}
+
+// message is what clients are told of a field's error
+func message(err error) string {
+	var ue *upstreamError
+	if !errors.As(err, &ue) {
+		return err.Error()
+	}
+
+	var apiErr *books.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return "the Books API quota is used up"
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return "the Books API rejected the server's api key"
+		}
+		return strings.TrimPrefix(apiErr.Error(), "books: ")
+	}
+
+	if errors.Is(err, errStatus) {
+		return "the Books API answered with an error"
+	}
+
+	switch books.ClassifyError(err, 0) {
+	case books.ClassTimeout:
+		return "the Books API didn't answer in time"
+	case books.ClassNetwork:
+		return "the Books API can't be reached"
+	case books.ClassTooLarge:
+		return "the Books API response is too large"
+	case books.ClassDecode:
+		return "the Books API response can't be decoded"
+	case books.ClassCanceled:
+		return "the request was canceled"
+	}
+
+	return "the Books API call failed"
+}
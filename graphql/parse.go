@@ -0,0 +1,548 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The parser covers the query side of the GraphQL language: operations,
+// variables, aliases, arguments, fragments and the skip and include
+// directives. Mutations and subscriptions aren't supported.
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind      string
+	name      string
+	variables []variableDef
+	selection []selection
+}
+
+type variableDef struct {
+	name     string
+	typ      string
+	nonNull  bool
+	fallback value
+}
+
+type fragment struct {
+	name      string
+	on        string
+	selection []selection
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment
+type selection interface{}
+
+type field struct {
+	alias      string
+	name       string
+	args       []argument
+	directives []directive
+	selection  []selection
+}
+
+func (f *field) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+
+	return f.name
+}
+
+type argument struct {
+	name string
+	val  value
+}
+
+type directive struct {
+	name string
+	args []argument
+}
+
+type fragmentSpread struct {
+	name       string
+	directives []directive
+}
+
+type inlineFragment struct {
+	on         string
+	directives []directive
+	selection  []selection
+}
+
+// value is a parsed literal, or a variable
+type value interface{}
+
+type variable string
+
+type enum string
+
+type objectValue []argument
+
+// SyntaxError is a query that can't be parsed
+type SyntaxError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("graphql: syntax error at %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// maxDepth is how deeply selection sets, list and object values and list
+// types may nest, the parser recursing once per level
+const maxDepth = 64
+
+type parser struct {
+	src   string
+	pos   int
+	tok   token
+	depth int
+}
+
+func parse(src string) (doc *document, err error) {
+	p := &parser{src: strings.TrimPrefix(src, "\uFEFF")}
+	defer func() {
+		if r := recover(); r != nil {
+			serr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, serr
+		}
+	}()
+	p.next()
+
+	return p.document(), nil
+}
+
+func (p *parser) fail(pos int, format string, args ...interface{}) {
+	line, col := 1, 1
+	for _, r := range p.src[:pos] {
+		if r == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+	panic(&SyntaxError{Line: line, Column: col, Msg: fmt.Sprintf(format, args...)})
+}
+
+// next lexes the next token into p.tok
+func (p *parser) next() {
+	// skip whitespace, commas and comments
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else {
+			break
+		}
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokPunct, text: "...", pos: start}
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokPunct, text: string(c), pos: start}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.src) && isNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = token{kind: tokName, text: p.src[start:p.pos], pos: start}
+	case c == '-' || c >= '0' && c <= '9':
+		p.number()
+	case c == '"':
+		p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.fail(start, "unexpected character %q", r)
+	}
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (p *parser) number() {
+	start := p.pos
+	kind := tokInt
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		n := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		if p.pos == n {
+			p.fail(p.pos, "expected a digit")
+		}
+	}
+	digits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = tokFloat
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = tokFloat
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	p.tok = token{kind: kind, text: p.src[start:p.pos], pos: start}
+}
+
+func (p *parser) string() {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			p.fail(start, "unterminated block string")
+		}
+		text := p.src[p.pos+3 : p.pos+3+end]
+		p.pos += end + 6
+		p.tok = token{kind: tokString, text: strings.TrimSpace(text), pos: start}
+		return
+	}
+
+	var b strings.Builder
+	p.pos++
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			p.fail(start, "unterminated string")
+		}
+		c := p.src[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+		if p.pos+1 >= len(p.src) {
+			p.fail(start, "unterminated string")
+		}
+		esc := p.src[p.pos+1]
+		p.pos += 2
+		switch esc {
+		case '"', '\\', '/':
+			b.WriteByte(esc)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+4 > len(p.src) {
+				p.fail(p.pos, "invalid unicode escape")
+			}
+			n, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+			if err != nil {
+				p.fail(p.pos, "invalid unicode escape")
+			}
+			b.WriteRune(rune(n))
+			p.pos += 4
+		default:
+			p.fail(p.pos-2, "invalid escape \\%c", esc)
+		}
+	}
+	p.tok = token{kind: tokString, text: b.String(), pos: start}
+}
+
+func (p *parser) peek(text string) bool {
+	return p.tok.kind == tokPunct && p.tok.text == text
+}
+
+func (p *parser) skip(text string) bool {
+	if p.peek(text) {
+		p.next()
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(text string) {
+	if !p.skip(text) {
+		p.fail(p.tok.pos, "expected %q, found %s", text, p.describe())
+	}
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokName {
+		p.fail(p.tok.pos, "expected a name, found %s", p.describe())
+	}
+	name := p.tok.text
+	p.next()
+
+	return name
+}
+
+// enter starts a nested level, failing past maxDepth
+func (p *parser) enter() {
+	p.depth++
+	if p.depth > maxDepth {
+		p.fail(p.tok.pos, "nested more than %d levels deep", maxDepth)
+	}
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) describe() string {
+	if p.tok.kind == tokEOF {
+		return "end of query"
+	}
+
+	return strconv.Quote(p.tok.text)
+}
+
+func (p *parser) document() *document {
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &operation{kind: "query", selection: p.selectionSet()})
+		case p.tok.kind == tokName && p.tok.text == "fragment":
+			p.next()
+			f := &fragment{name: p.name()}
+			if p.tok.kind != tokName || p.tok.text != "on" {
+				p.fail(p.tok.pos, "expected \"on\", found %s", p.describe())
+			}
+			p.next()
+			f.on = p.name()
+			p.directives()
+			f.selection = p.selectionSet()
+			doc.fragments[f.name] = f
+		case p.tok.kind == tokName:
+			doc.operations = append(doc.operations, p.operation())
+		default:
+			p.fail(p.tok.pos, "expected an operation, found %s", p.describe())
+		}
+	}
+	if len(doc.operations) == 0 {
+		p.fail(p.pos, "no operation")
+	}
+
+	return doc
+}
+
+func (p *parser) operation() *operation {
+	op := &operation{kind: p.name()}
+	if op.kind != "query" && op.kind != "mutation" && op.kind != "subscription" {
+		p.fail(p.tok.pos, "unknown operation %q", op.kind)
+	}
+	if p.tok.kind == tokName {
+		op.name = p.name()
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			p.expect("$")
+			v := variableDef{name: p.name()}
+			p.expect(":")
+			v.typ, v.nonNull = p.typeRef()
+			if p.skip("=") {
+				v.fallback = p.value(true)
+			}
+			op.variables = append(op.variables, v)
+		}
+	}
+	p.directives()
+	op.selection = p.selectionSet()
+
+	return op
+}
+
+// typeRef parses a type, e.g. [String!]!
+func (p *parser) typeRef() (string, bool) {
+	var typ string
+	if p.peek("[") {
+		p.enter()
+		p.next()
+		inner, nonNull := p.typeRef()
+		if nonNull {
+			inner += "!"
+		}
+		p.expect("]")
+		p.leave()
+		typ = "[" + inner + "]"
+	} else {
+		typ = p.name()
+	}
+
+	return typ, p.skip("!")
+}
+
+func (p *parser) selectionSet() []selection {
+	p.enter()
+	p.expect("{")
+	var set []selection
+	for !p.skip("}") {
+		set = append(set, p.selection())
+	}
+	if len(set) == 0 {
+		p.fail(p.tok.pos, "empty selection set")
+	}
+	p.leave()
+
+	return set
+}
+
+func (p *parser) selection() selection {
+	if p.skip("...") {
+		if p.tok.kind == tokName && p.tok.text != "on" {
+			return &fragmentSpread{name: p.name(), directives: p.directives()}
+		}
+		f := &inlineFragment{}
+		if p.tok.kind == tokName {
+			p.next()
+			f.on = p.name()
+		}
+		f.directives = p.directives()
+		f.selection = p.selectionSet()
+		return f
+	}
+
+	f := &field{name: p.name()}
+	if p.skip(":") {
+		f.alias, f.name = f.name, p.name()
+	}
+	f.args = p.arguments(false)
+	f.directives = p.directives()
+	if p.peek("{") {
+		f.selection = p.selectionSet()
+	}
+
+	return f
+}
+
+func (p *parser) arguments(constant bool) []argument {
+	var args []argument
+	if !p.skip("(") {
+		return nil
+	}
+	for !p.skip(")") {
+		name := p.name()
+		p.expect(":")
+		args = append(args, argument{name: name, val: p.value(constant)})
+	}
+
+	return args
+}
+
+func (p *parser) directives() []directive {
+	var ds []directive
+	for p.skip("@") {
+		ds = append(ds, directive{name: p.name(), args: p.arguments(false)})
+	}
+
+	return ds
+}
+
+// value parses a value, variables being refused in constant ones
+func (p *parser) value(constant bool) value {
+	tok := p.tok
+	switch {
+	case p.skip("$"):
+		if constant {
+			p.fail(tok.pos, "unexpected variable")
+		}
+		return variable(p.name())
+	case p.skip("["):
+		p.enter()
+		list := []value{}
+		for !p.skip("]") {
+			list = append(list, p.value(constant))
+		}
+		p.leave()
+		return list
+	case p.skip("{"):
+		p.enter()
+		obj := objectValue{}
+		for !p.skip("}") {
+			name := p.name()
+			p.expect(":")
+			obj = append(obj, argument{name: name, val: p.value(constant)})
+		}
+		p.leave()
+		return obj
+	}
+
+	p.next()
+	switch tok.kind {
+	case tokInt:
+		n, err := strconv.Atoi(tok.text)
+		if err != nil {
+			p.fail(tok.pos, "invalid int %s", tok.text)
+		}
+		return n
+	case tokFloat:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			p.fail(tok.pos, "invalid float %s", tok.text)
+		}
+		return f
+	case tokString:
+		return tok.text
+	case tokName:
+		switch tok.text {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enum(tok.text)
+	}
+	p.fail(tok.pos, "expected a value, found %q", tok.text)
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := parse(`
+		# the books of a list
+		query Books($name: String!, $offset: Int = 20) {
+			fiction: list(name: $name, offset: $offset) {
+				books @include(if: true) { ...info }
+			}
+		}
+		fragment info on Book { title, rank }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(doc.operations) != 1 || len(doc.fragments) != 1 {
+		t.Fatalf("got %d operations and %d fragments", len(doc.operations), len(doc.fragments))
+	}
+	op := doc.operations[0]
+	if op.kind != "query" || op.name != "Books" {
+		t.Errorf("got %s %s", op.kind, op.name)
+	}
+	if len(op.variables) != 2 || !op.variables[0].nonNull || op.variables[1].fallback != 20 {
+		t.Errorf("got variables %+v", op.variables)
+	}
+
+	f := op.selection[0].(*field)
+	if f.key() != "fiction" || f.name != "list" || len(f.args) != 2 || f.args[0].val != variable("name") {
+		t.Errorf("got field %+v", f)
+	}
+	books := f.selection[0].(*field)
+	if len(books.directives) != 1 || books.directives[0].name != "include" {
+		t.Errorf("got directives %+v", books.directives)
+	}
+	if spread, ok := books.selection[0].(*fragmentSpread); !ok || spread.name != "info" {
+		t.Errorf("got %+v, want a spread of info", books.selection[0])
+	}
+	if fr := doc.fragments["info"]; fr.on != "Book" || len(fr.selection) != 2 {
+		t.Errorf("got fragment %+v", fr)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	doc, err := parse(`{ f(a: -1.5e2, b: "xé\n", c: [1, null], d: {e: HARDCOVER}, g: """ block """) }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := doc.operations[0].selection[0].(*field).args
+	if args[0].val != -150.0 {
+		t.Errorf("got %v, want -150", args[0].val)
+	}
+	if args[1].val != "xé\n" {
+		t.Errorf("got %q", args[1].val)
+	}
+	if list := args[2].val.([]value); len(list) != 2 || list[0] != 1 || list[1] != nil {
+		t.Errorf("got %v", list)
+	}
+	if obj := args[3].val.(objectValue); obj[0].name != "e" || obj[0].val != enum("HARDCOVER") {
+		t.Errorf("got %v", obj)
+	}
+	if args[4].val != "block" {
+		t.Errorf("got %q", args[4].val)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`{ list(name: ) { title } }`,
+		`{ list { title }`,
+		`query Q($x) { f }`,
+		`{ f(a: "unterminated) }`,
+		`{ f(a: $x) } fragment f on Book { }`,
+	} {
+		_, err := parse(src)
+		var serr *SyntaxError
+		if !errors.As(err, &serr) {
+			t.Errorf("%q: got %v, want a syntax error", src, err)
+		}
+	}
+
+	_, err := parse("{\n  list(name: ) { title } }")
+	if serr, ok := err.(*SyntaxError); !ok || serr.Line != 2 || serr.Column != 14 {
+		t.Errorf("got %v, want an error at 2:14", err)
+	}
+}
+
+func TestParseDepth(t *testing.T) {
+	deep := func(open, close string, n int) string {
+		return strings.Repeat(open, n) + strings.Repeat(close, n)
+	}
+	for _, src := range []string{
+		"{ f(a: " + deep("[", "]", 100000) + ") }",
+		"{ f(a: " + deep("{a: ", "}", 100000) + ") }",
+		"query Q($x: " + deep("[", "]", 100000) + "String) { f }",
+		deep("{ f ", "}", 100000),
+	} {
+		_, err := parse(src)
+		var serr *SyntaxError
+		if !errors.As(err, &serr) || !strings.Contains(serr.Msg, "nested more than") {
+			t.Errorf("%.20s...: got %v, want a nesting error", src, err)
+		}
+	}
+
+	if _, err := parse("{ f(a: " + deep("[", "]", maxDepth-1) + ") }"); err != nil {
+		t.Errorf("got %v for a list nested %d levels", err, maxDepth-1)
+	}
+}
@@ -0,0 +1,421 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// listInfo is a ListInfo, a list the API knows of
+type listInfo struct {
+	Name                string
+	DisplayName         string
+	Frequency           string
+	OldestPublishedDate string
+	NewestPublishedDate string
+}
+
+// list is a List, an edition of a best sellers list
+type list struct {
+	Name            string
+	DisplayName     string
+	PublishedDate   string
+	BestsellersDate string
+	Books           []*book
+}
+
+// book is a Book, either from a list or from a history search
+type book struct {
+	Title        string
+	Author       string
+	Description  string
+	Publisher    string
+	ISBN13       string
+	ISBN10       string
+	Rank         int
+	RankLastWeek int
+	WeeksOnList  int
+	Image        string
+	AmazonURL    string
+
+	// history is set for books found by a history search
+	history []*rankHistory
+}
+
+// rankHistory is a RankHistory, a week a book spent on a list
+type rankHistory struct {
+	List            string
+	DisplayName     string
+	Rank            int
+	WeeksOnList     int
+	PublishedDate   string
+	BestsellersDate string
+}
+
+// review is a Review
+type review struct {
+	URL             string
+	Byline          string
+	Summary         string
+	PublicationDate string
+	BookTitle       string
+	BookAuthor      string
+	ISBN13          []string
+}
+
+// attr is a field resolved to the Go field name of its parent,
+// zero values being null
+func attr(typ, name string) *fieldDef {
+	return &fieldDef{typ: typ, resolve: func(_ context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+		v := reflect.Indirect(reflect.ValueOf(source)).FieldByName(name)
+		if v.IsZero() {
+			return nil, nil
+		}
+		return v.Interface(), nil
+	}}
+}
+
+// searchArgs are the arguments of the fields searching by book
+var searchArgs = []argDef{{name: "isbn", typ: "String"}, {name: "title", typ: "String"}, {name: "author", typ: "String"}}
+
+// newSchema builds the schema, resolving through h's loader
+func newSchema(h *Handler) *schema {
+	query := &object{name: "Query", fields: map[string]*fieldDef{
+		"lists": {typ: "[ListInfo]", resolve: func(ctx context.Context, _ interface{}, _ map[string]interface{}) (interface{}, error) {
+			return h.lists(ctx)
+		}},
+		"list": {
+			typ: "List",
+			args: []argDef{
+				{name: "name", typ: "String!"},
+				{name: "date", typ: "String", fallback: "current"},
+				{name: "offset", typ: "Int", fallback: 0},
+			},
+			resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+				return h.list(ctx, args["name"].(string), args["date"].(string), args["offset"].(int))
+			},
+		},
+		"history": {typ: "[Book]", args: searchArgs, resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			qp, err := searchParams(args)
+			if err != nil {
+				return nil, err
+			}
+			return h.history(ctx, qp)
+		}},
+		"book": {typ: "Book", args: []argDef{{name: "isbn", typ: "String!"}}, resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			found, err := h.history(ctx, books.QueryParam{"isbn": args["isbn"].(string)})
+			if err != nil || len(found) == 0 {
+				return nil, err
+			}
+			return found[0], nil
+		}},
+		"reviews": {typ: "[Review]", args: searchArgs, resolve: func(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+			qp, err := searchParams(args)
+			if err != nil {
+				return nil, err
+			}
+			return h.reviews(ctx, qp)
+		}},
+	}}
+
+	listInfoType := &object{name: "ListInfo", fields: map[string]*fieldDef{
+		"name":                attr("String", "Name"),
+		"displayName":         attr("String", "DisplayName"),
+		"frequency":           attr("String", "Frequency"),
+		"oldestPublishedDate": attr("String", "OldestPublishedDate"),
+		"newestPublishedDate": attr("String", "NewestPublishedDate"),
+	}}
+
+	listType := &object{name: "List", fields: map[string]*fieldDef{
+		"name":            attr("String", "Name"),
+		"displayName":     attr("String", "DisplayName"),
+		"publishedDate":   attr("String", "PublishedDate"),
+		"bestsellersDate": attr("String", "BestsellersDate"),
+		"books":           attr("[Book]", "Books"),
+	}}
+
+	bookType := &object{name: "Book", fields: map[string]*fieldDef{
+		"title":        attr("String", "Title"),
+		"author":       attr("String", "Author"),
+		"description":  attr("String", "Description"),
+		"publisher":    attr("String", "Publisher"),
+		"isbn13":       attr("String", "ISBN13"),
+		"isbn10":       attr("String", "ISBN10"),
+		"rank":         attr("Int", "Rank"),
+		"rankLastWeek": attr("Int", "RankLastWeek"),
+		"weeksOnList":  attr("Int", "WeeksOnList"),
+		"image":        attr("String", "Image"),
+		"amazonUrl":    attr("String", "AmazonURL"),
+		"rankHistory": {typ: "[RankHistory]", resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			b := source.(*book)
+			if b.history != nil || b.ISBN13 == "" {
+				return b.history, nil
+			}
+			found, err := h.history(ctx, books.QueryParam{"isbn": b.ISBN13})
+			if err != nil || len(found) == 0 {
+				return nil, err
+			}
+			return found[0].history, nil
+		}},
+		"reviews": {typ: "[Review]", resolve: func(ctx context.Context, source interface{}, _ map[string]interface{}) (interface{}, error) {
+			b := source.(*book)
+			if b.ISBN13 == "" {
+				return nil, nil
+			}
+			return h.reviews(ctx, books.QueryParam{"isbn": b.ISBN13})
+		}},
+	}}
+
+	rankHistoryType := &object{name: "RankHistory", fields: map[string]*fieldDef{
+		"list":            attr("String", "List"),
+		"displayName":     attr("String", "DisplayName"),
+		"rank":            attr("Int", "Rank"),
+		"weeksOnList":     attr("Int", "WeeksOnList"),
+		"publishedDate":   attr("String", "PublishedDate"),
+		"bestsellersDate": attr("String", "BestsellersDate"),
+	}}
+
+	reviewType := &object{name: "Review", fields: map[string]*fieldDef{
+		"url":             attr("String", "URL"),
+		"byline":          attr("String", "Byline"),
+		"summary":         attr("String", "Summary"),
+		"publicationDate": attr("String", "PublicationDate"),
+		"bookTitle":       attr("String", "BookTitle"),
+		"bookAuthor":      attr("String", "BookAuthor"),
+		"isbn13":          attr("[String]", "ISBN13"),
+	}}
+
+	s := &schema{query: query, types: make(map[string]*object)}
+	for _, o := range []*object{query, listInfoType, listType, bookType, rankHistoryType, reviewType} {
+		s.types[o.name] = o
+	}
+
+	return s
+}
+
+func searchParams(args map[string]interface{}) (books.QueryParam, error) {
+	qp := books.QueryParam{}
+	for _, name := range []string{"isbn", "title", "author"} {
+		if v, ok := args[name].(string); ok && v != "" {
+			qp[name] = v
+		}
+	}
+	if len(qp) == 0 {
+		return nil, errors.New("one of isbn, title or author is required")
+	}
+
+	return qp, nil
+}
+
+// errStatus is a response decoded from an error payload, as clients
+// without WithStatusErrors do, which mustn't be cached as an empty result
+var errStatus = errors.New("graphql: the Books API answered with an error")
+
+func checkStatus(status string) error {
+	if status != "OK" {
+		return fmt.Errorf("%w: status %q", errStatus, status)
+	}
+
+	return nil
+}
+
+func (h *Handler) lists(ctx context.Context) ([]*listInfo, error) {
+	v, err := h.loader.load(ctx, "names", func() (interface{}, error) {
+		names, err := h.source.GetBestSellersListNames()
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStatus(names.Status); err != nil {
+			return nil, err
+		}
+		out := make([]*listInfo, len(names.Results))
+		for i, res := range names.Results {
+			out[i] = &listInfo{
+				Name:                res.ListNameEncoded,
+				DisplayName:         res.DisplayName,
+				Frequency:           res.Updated,
+				OldestPublishedDate: res.OldestPublishedDate,
+				NewestPublishedDate: res.NewestPublishedDate,
+			}
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*listInfo), nil
+}
+
+func (h *Handler) list(ctx context.Context, name, date string, offset int) (*list, error) {
+	var qp books.QueryParam
+	if offset > 0 {
+		qp = books.QueryParam{"offset": strconv.Itoa(offset)}
+	}
+	key := fmt.Sprintf("list/%s/%s?%s", date, name, qp)
+	v, err := h.loader.load(ctx, key, func() (interface{}, error) {
+		res, err := h.source.GetBestSellersListByDate(date, name, qp)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStatus(res.Status); err != nil {
+			return nil, err
+		}
+		l := &list{
+			Name:            res.Results.ListName,
+			DisplayName:     res.Results.DisplayName,
+			PublishedDate:   res.Results.PublishedDate,
+			BestsellersDate: res.Results.BestsellersDate,
+		}
+		for _, b := range res.Results.Books {
+			l.Books = append(l.Books, &book{
+				Title:        b.Title,
+				Author:       b.Author,
+				Description:  b.Description,
+				Publisher:    b.Publisher,
+				ISBN13:       b.PrimaryISBN13,
+				ISBN10:       b.PrimaryISBN10,
+				Rank:         b.Rank,
+				RankLastWeek: b.RankLastWeek,
+				WeeksOnList:  b.WeeksOnList,
+				Image:        b.BookImage,
+				AmazonURL:    b.AmazonProductURL,
+			})
+		}
+		return l, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*list), nil
+}
+
+func (h *Handler) history(ctx context.Context, qp books.QueryParam) ([]*book, error) {
+	v, err := h.loader.load(ctx, "history?"+qp.String(), func() (interface{}, error) {
+		res, err := h.source.GetBestSellersListHistory(qp)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStatus(res.Status); err != nil {
+			return nil, err
+		}
+		out := make([]*book, len(res.Results))
+		for i, hb := range res.Results {
+			b := &book{
+				Title:       hb.Title,
+				Author:      hb.Author,
+				Description: hb.Description,
+				Publisher:   hb.Publisher,
+				history:     []*rankHistory{},
+			}
+			if len(hb.ISBNs) > 0 {
+				b.ISBN13, b.ISBN10 = hb.ISBNs[0].ISBN13, hb.ISBNs[0].ISBN10
+			}
+			for _, r := range hb.RanksHistory {
+				b.history = append(b.history, &rankHistory{
+					List:            r.ListName,
+					DisplayName:     r.DisplayName,
+					Rank:            r.Rank,
+					WeeksOnList:     r.WeeksOnList,
+					PublishedDate:   r.PublishedDate,
+					BestsellersDate: r.BestsellersDate,
+				})
+				if b.ISBN13 == "" {
+					b.ISBN13, b.ISBN10 = r.PrimaryISBN13, r.PrimaryISBN10
+				}
+			}
+			out[i] = b
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*book), nil
+}
+
+func (h *Handler) reviews(ctx context.Context, qp books.QueryParam) ([]*review, error) {
+	v, err := h.loader.load(ctx, "reviews?"+qp.String(), func() (interface{}, error) {
+		res, err := h.source.GetReviews(qp)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStatus(res.Status); err != nil {
+			return nil, err
+		}
+		out := make([]*review, len(res.Results))
+		for i, r := range res.Results {
+			out[i] = &review{
+				URL:             r.URL,
+				Byline:          r.ByLine,
+				Summary:         r.Summary,
+				PublicationDate: r.PublicationDt,
+				BookTitle:       r.BookTitle,
+				BookAuthor:      r.BookAuthor,
+				ISBN13:          r.ISBN13,
+			}
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.([]*review), nil
+}
+
+// sdl writes the schema in the GraphQL schema definition language
+func (s *schema) sdl() string {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		if name != s.query.name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{s.query.name}, names...)
+
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		o := s.types[name]
+		fmt.Fprintf(&b, "type %s {\n", o.name)
+		fields := make([]string, 0, len(o.fields))
+		for f := range o.fields {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+		for _, f := range fields {
+			def := o.fields[f]
+			b.WriteString("  " + f)
+			if len(def.args) > 0 {
+				var args []string
+				for _, a := range def.args {
+					arg := a.name + ": " + a.typ
+					switch v := a.fallback.(type) {
+					case string:
+						arg += " = " + strconv.Quote(v)
+					case int:
+						arg += " = " + strconv.Itoa(v)
+					}
+					args = append(args, arg)
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + def.typ + "\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,101 @@
+// Package singleflight provides a duplicate call suppression mechanism,
+// so that concurrent callers asking for the same key share one call
+package singleflight
+
+import "sync"
+
+type call struct {
+	wg   sync.WaitGroup
+	val  interface{}
+	err  error
+	dups int
+}
+
+// Group represents a class of work where calls with the same key
+// are coalesced. The zero value is ready to use.
+type Group struct {
+	mu sync.Mutex
+	m  map[string]*call
+}
+
+// Do executes fn, making sure only one execution is in-flight for a given key
+// at a time. Callers that come in while a call is in-flight wait for it and
+// receive the same results. shared reports whether the result was given
+// to more than one caller.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	c, leader := g.join(key)
+	if !leader {
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	g.run(key, c, fn)
+
+	return c.val, c.err, c.dups > 0
+}
+
+// DoChan is like Do but returns a channel that receives the result
+// once it is ready, so that the caller can stop waiting without
+// affecting the call or the other callers waiting on it.
+func (g *Group) DoChan(key string, fn func() (interface{}, error)) <-chan Result {
+	ch := make(chan Result, 1)
+	c, leader := g.join(key)
+	if leader {
+		go g.run(key, c, fn)
+	}
+	go func() {
+		c.wg.Wait()
+		g.mu.Lock()
+		shared := c.dups > 0
+		g.mu.Unlock()
+		ch <- Result{Val: c.val, Err: c.err, Shared: shared}
+	}()
+
+	return ch
+}
+
+// Result holds the results of DoChan
+type Result struct {
+	Val    interface{}
+	Err    error
+	Shared bool
+}
+
+// Waiting reports how many callers share the in-flight call for key,
+// the one making it included, or 0 if there is none
+func (g *Group) Waiting(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c, ok := g.m[key]
+	if !ok {
+		return 0
+	}
+
+	return c.dups + 1
+}
+
+func (g *Group) join(key string) (*call, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		return c, false
+	}
+	c := new(call)
+	c.wg.Add(1)
+	g.m[key] = c
+
+	return c, true
+}
+
+func (g *Group) run(key string, c *call, fn func() (interface{}, error)) {
+	c.val, c.err = fn()
+
+	g.mu.Lock()
+	delete(g.m, key)
+	g.mu.Unlock()
+	c.wg.Done()
+}
@@ -0,0 +1,102 @@
+package singleflight
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	var g Group
+	v, err, _ := g.Do("key", func() (interface{}, error) {
+		return "bar", nil
+	})
+	if got, want := v, "bar"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	someErr := errors.New("some error")
+	_, err, _ = g.Do("key", func() (interface{}, error) {
+		return nil, someErr
+	})
+	if err != someErr {
+		t.Errorf("got error %v, want %v", err, someErr)
+	}
+}
+
+func TestDoDupSuppress(t *testing.T) {
+	var g Group
+	var calls int32
+	release := make(chan struct{})
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "bar", nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err, _ := g.Do("key", fn)
+			if err != nil || v != "bar" {
+				t.Errorf("got %v, %v", v, err)
+			}
+		}()
+	}
+	// give the goroutines a chance to join the in-flight call
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("number of calls = %d; want 1", got)
+	}
+}
+
+func TestDoChan(t *testing.T) {
+	var g Group
+	res := <-g.DoChan("key", func() (interface{}, error) {
+		return 42, nil
+	})
+	if res.Val != 42 || res.Err != nil || res.Shared {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestWaiting(t *testing.T) {
+	var g Group
+	if n := g.Waiting("key"); n != 0 {
+		t.Errorf("got %d waiting, want 0", n)
+	}
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Do("key", func() (interface{}, error) {
+				<-release
+				return nil, nil
+			})
+		}()
+	}
+	for g.Waiting("key") < 3 {
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+
+	if n := g.Waiting("key"); n != 0 {
+		t.Errorf("got %d waiting after the call, want 0", n)
+	}
+}
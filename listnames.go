@@ -0,0 +1,289 @@
+package books
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ListName is the encoded name of a best sellers list,
+// as found in list_name_encoded
+type ListName string
+
+// Known best sellers lists. GetBestSellersListNames has the full, current set.
+const (
+	CombinedPrintAndEBookFiction    ListName = "combined-print-and-e-book-fiction"
+	CombinedPrintAndEBookNonfiction ListName = "combined-print-and-e-book-nonfiction"
+	HardcoverFiction                ListName = "hardcover-fiction"
+	HardcoverNonfiction             ListName = "hardcover-nonfiction"
+	TradeFictionPaperback           ListName = "trade-fiction-paperback"
+	PaperbackNonfiction             ListName = "paperback-nonfiction"
+	AdviceHowToAndMiscellaneous     ListName = "advice-how-to-and-miscellaneous"
+	ChildrensMiddleGradeHardcover   ListName = "childrens-middle-grade-hardcover"
+	PictureBooks                    ListName = "picture-books"
+	SeriesBooks                     ListName = "series-books"
+	YoungAdultHardcover             ListName = "young-adult-hardcover"
+	AudioFiction                    ListName = "audio-fiction"
+	AudioNonfiction                 ListName = "audio-nonfiction"
+	BusinessBooks                   ListName = "business-books"
+	GraphicBooksAndManga            ListName = "graphic-books-and-manga"
+	MassMarketMonthly               ListName = "mass-market-monthly"
+	MiddleGradePaperbackMonthly     ListName = "middle-grade-paperback-monthly"
+	YoungAdultPaperbackMonthly      ListName = "young-adult-paperback-monthly"
+)
+
+// Frequency is how often a list is updated
+type Frequency string
+
+// Update frequencies, as found in the updated field of list names
+const (
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// period returns the longest time between two editions of a list
+func (f Frequency) period() time.Duration {
+	if f == Monthly {
+		return 31 * 24 * time.Hour
+	}
+
+	return 7 * 24 * time.Hour
+}
+
+// DateLayout is the layout of the dates used by the Books API
+const DateLayout = "2006-01-02"
+
+var (
+	// ErrUnknownList is returned when a list name can't be resolved
+	ErrUnknownList = errors.New("books: unknown list")
+	// ErrAmbiguousList is returned when a list name resolves to several lists
+	ErrAmbiguousList = errors.New("books: ambiguous list name")
+	// ErrDateOutOfRange is returned for dates a list wasn't published on
+	ErrDateOutOfRange = errors.New("books: date out of the list's range")
+)
+
+// ListInfo describes a best sellers list
+type ListInfo struct {
+	Name        ListName
+	ListName    string
+	DisplayName string
+	Frequency   Frequency
+	Oldest      time.Time
+	Newest      time.Time
+
+	tokens []string
+}
+
+// ListResolver maps display names or loosely typed input to lists
+type ListResolver struct {
+	client *Client
+	lists  []ListInfo
+}
+
+// NewListResolver builds a ListResolver from the lists returned by
+// GetBestSellersListNames
+func (c *Client) NewListResolver() (*ListResolver, error) {
+	names, err := c.GetBestSellersListNames()
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewListResolverFromNames(names)
+	if err != nil {
+		return nil, err
+	}
+	r.client = c
+
+	return r, nil
+}
+
+// NewListResolverFromNames builds a ListResolver from an already fetched
+// Names response. Its GetBestSellersListByDate method can't be used.
+func NewListResolverFromNames(names *Names) (*ListResolver, error) {
+	r := &ListResolver{}
+	for _, res := range names.Results {
+		info := ListInfo{
+			Name:        ListName(res.ListNameEncoded),
+			ListName:    res.ListName,
+			DisplayName: res.DisplayName,
+			Frequency:   Frequency(res.Updated),
+			tokens:      tokenize(strings.Replace(res.ListNameEncoded, "-", " ", -1)),
+		}
+		var err error
+		if info.Oldest, err = time.Parse(DateLayout, res.OldestPublishedDate); err != nil {
+			return nil, fmt.Errorf("books: list %s: %v", res.ListNameEncoded, err)
+		}
+		if info.Newest, err = time.Parse(DateLayout, res.NewestPublishedDate); err != nil {
+			return nil, fmt.Errorf("books: list %s: %v", res.ListNameEncoded, err)
+		}
+		r.lists = append(r.lists, info)
+	}
+	sort.Slice(r.lists, func(i, j int) bool { return r.lists[i].Name < r.lists[j].Name })
+
+	return r, nil
+}
+
+// Lists returns every known list, sorted by name
+func (r *ListResolver) Lists() []ListInfo {
+	lists := make([]ListInfo, len(r.lists))
+	copy(lists, r.lists)
+
+	return lists
+}
+
+// Resolve finds the list matching input, which can be an encoded name
+// ("hardcover-fiction"), a list or display name ("Hardcover Fiction")
+// or an abbreviation ("hc fiction").
+func (r *ListResolver) Resolve(input string) (ListInfo, error) {
+	want := tokenize(input)
+	if len(want) == 0 {
+		return ListInfo{}, fmt.Errorf("%w: %q", ErrUnknownList, input)
+	}
+
+	for _, l := range r.lists {
+		if string(l.Name) == input {
+			return l, nil
+		}
+	}
+	for _, l := range r.lists {
+		if equalTokens(tokenize(l.ListName), want) || equalTokens(tokenize(l.DisplayName), want) {
+			return l, nil
+		}
+	}
+
+	// otherwise take the lists containing every word of the input,
+	// preferring the ones with the fewest extra words
+	var best []ListInfo
+	bestExtra := -1
+	for _, l := range r.lists {
+		if !containsTokens(l.tokens, want) {
+			continue
+		}
+		extra := len(l.tokens) - len(want)
+		switch {
+		case bestExtra < 0 || extra < bestExtra:
+			best, bestExtra = []ListInfo{l}, extra
+		case extra == bestExtra:
+			best = append(best, l)
+		}
+	}
+
+	switch len(best) {
+	case 0:
+		return ListInfo{}, fmt.Errorf("%w: %q", ErrUnknownList, input)
+	case 1:
+		return best[0], nil
+	default:
+		names := make([]string, len(best))
+		for i, l := range best {
+			names[i] = string(l.Name)
+		}
+		return ListInfo{}, fmt.Errorf("%w: %q matches %s", ErrAmbiguousList, input, strings.Join(names, ", "))
+	}
+}
+
+// ValidateDate reports whether a list can be requested for date,
+// which is either "current" or a YYYY-MM-DD date.
+func (l ListInfo) ValidateDate(date string) error {
+	if date == "current" {
+		return nil
+	}
+	d, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return fmt.Errorf("books: invalid date %q: %v", date, err)
+	}
+	if d.Before(l.Oldest) || d.After(l.Newest.Add(l.Frequency.period())) {
+		return l.outOfRange(d)
+	}
+
+	return nil
+}
+
+// GetBestSellersListByDate resolves list and validates date
+// before getting the list from the API.
+func (r *ListResolver) GetBestSellersListByDate(date, list string, qp QueryParam) (*ListByDate, error) {
+	if r.client == nil {
+		return nil, errors.New("books: list resolver has no client")
+	}
+	info, err := r.Resolve(list)
+	if err != nil {
+		return nil, err
+	}
+	if err := info.ValidateDate(date); err != nil {
+		return nil, err
+	}
+
+	return r.client.GetBestSellersListByDate(date, string(info.Name), qp)
+}
+
+// abbreviations are the short forms accepted by Resolve
+var abbreviations = map[string][]string{
+	"hc":          {"hardcover"},
+	"hardback":    {"hardcover"},
+	"pb":          {"paperback"},
+	"tp":          {"trade", "paperback"},
+	"nf":          {"nonfiction"},
+	"ya":          {"young", "adult"},
+	"mg":          {"middle", "grade"},
+	"ebook":       {"e", "book"},
+	"children":    {"childrens"},
+	"kids":        {"childrens"},
+	"audiobook":   {"audio"},
+	"audiobooks":  {"audio"},
+	"howto":       {"how", "to"},
+	"misc":        {"miscellaneous"},
+	"list":        {},
+	"bestsellers": {},
+}
+
+// tokenize lowercases s, drops punctuation and expands abbreviations
+func tokenize(s string) []string {
+	s = strings.ToLower(s)
+	s = strings.Replace(s, "&", " and ", -1)
+	s = strings.Replace(s, "non-fiction", "nonfiction", -1)
+	s = strings.Replace(s, "e-book", "e book", -1)
+	s = strings.Replace(s, "'", "", -1)
+	s = strings.Replace(s, "’", "", -1)
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+
+	var tokens []string
+	for _, f := range fields {
+		if exp, ok := abbreviations[f]; ok {
+			tokens = append(tokens, exp...)
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+
+	return tokens
+}
+
+func equalTokens(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsTokens(haystack, needles []string) bool {
+	set := make(map[string]bool, len(haystack))
+	for _, t := range haystack {
+		set[t] = true
+	}
+	for _, n := range needles {
+		if !set[n] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,128 @@
+package books
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+const namesJSON = `{"status": "OK", "num_results": 4, "results": [
+	{"list_name": "Combined Print and E-Book Fiction", "display_name": "Combined Print & E-Book Fiction", "list_name_encoded": "combined-print-and-e-book-fiction", "oldest_published_date": "2011-02-13", "newest_published_date": "2016-03-20", "updated": "WEEKLY"},
+	{"list_name": "Hardcover Fiction", "display_name": "Hardcover Fiction", "list_name_encoded": "hardcover-fiction", "oldest_published_date": "2008-06-08", "newest_published_date": "2016-03-20", "updated": "WEEKLY"},
+	{"list_name": "Audio Fiction", "display_name": "Audio Fiction", "list_name_encoded": "audio-fiction", "oldest_published_date": "2018-03-11", "newest_published_date": "2021-07-11", "updated": "MONTHLY"},
+	{"list_name": "Young Adult Paperback Monthly", "display_name": "Young Adult Paperback Monthly", "list_name_encoded": "young-adult-paperback-monthly", "oldest_published_date": "2018-03-11", "newest_published_date": "2021-07-11", "updated": "MONTHLY"}
+]}`
+
+func newTestResolver(t *testing.T) *ListResolver {
+	t.Helper()
+	var names Names
+	if err := json.Unmarshal([]byte(namesJSON), &names); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r, err := NewListResolverFromNames(&names)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return r
+}
+
+func TestResolve(t *testing.T) {
+	r := newTestResolver(t)
+
+	tests := []struct {
+		input string
+		want  ListName
+		err   error
+	}{
+		{"hardcover-fiction", HardcoverFiction, nil},
+		{"Hardcover Fiction", HardcoverFiction, nil},
+		{"hc fiction", HardcoverFiction, nil},
+		{"Combined Print & E-Book Fiction", CombinedPrintAndEBookFiction, nil},
+		{"combined ebook fiction", CombinedPrintAndEBookFiction, nil},
+		{"YA paperback", YoungAdultPaperbackMonthly, nil},
+		{"fiction", "", ErrAmbiguousList},
+		{"cookbooks", "", ErrUnknownList},
+		{"", "", ErrUnknownList},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := r.Resolve(tt.input)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("got %v, want %v", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDate(t *testing.T) {
+	r := newTestResolver(t)
+	hc, err := r.Resolve("hardcover-fiction")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hc.Frequency != Weekly {
+		t.Errorf("got frequency %v, want %v", hc.Frequency, Weekly)
+	}
+
+	tests := []struct {
+		date string
+		err  error
+	}{
+		{"current", nil},
+		{"2015-07-04", nil},
+		{"2008-06-08", nil},
+		{"2016-03-25", nil},
+		{"2001-01-01", ErrDateOutOfRange},
+		{"2030-01-01", ErrDateOutOfRange},
+	}
+	for _, tt := range tests {
+		if err := hc.ValidateDate(tt.date); !errors.Is(err, tt.err) {
+			t.Errorf("ValidateDate(%v) = %v, want %v", tt.date, err, tt.err)
+		}
+	}
+
+	if err := hc.ValidateDate("04/07/2015"); err == nil {
+		t.Errorf("expected an error for a malformed date")
+	}
+}
+
+func TestResolverGetBestSellersListByDate(t *testing.T) {
+	var requested []string
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			requested = append(requested, r.URL.Path)
+			data := `{"status": "OK"}`
+			if r.URL.Path == "/svc/books/v3"+NamesEndpoint {
+				data = namesJSON
+			}
+			body := ioutil.NopCloser(bytes.NewReader([]byte(data)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+	r, err := c.NewListResolver()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := r.GetBestSellersListByDate("1999-01-01", "hc fiction", nil); !errors.Is(err, ErrDateOutOfRange) {
+		t.Errorf("got error %v, want %v", err, ErrDateOutOfRange)
+	}
+	if _, err := r.GetBestSellersListByDate("2015-07-04", "hc fiction", nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	want := []string{"/svc/books/v3/lists/names.json", "/svc/books/v3/lists/2015-07-04/hardcover-fiction.json"}
+	if len(requested) != len(want) || requested[0] != want[0] || requested[1] != want[1] {
+		t.Errorf("got requests %v, want %v", requested, want)
+	}
+}
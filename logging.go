@@ -0,0 +1,129 @@
+package books
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// LogLevels are the levels the Client logs its calls at
+type LogLevels struct {
+	// Response is the level of calls that got a response, and of their decoding
+	Response slog.Level
+	// Failure is the level of failed calls, error statuses and decoding errors
+	Failure slog.Level
+}
+
+// DefaultLogLevels log responses at debug level and failures as warnings
+var DefaultLogLevels = LogLevels{
+	Response: slog.LevelDebug,
+	Failure:  slog.LevelWarn,
+}
+
+// snippetSize is the length of the body logged on decoding errors
+const snippetSize = 512
+
+// WithLogger makes the Client log every call with the endpoint, the
+// URL with the api key redacted, the status, duration and attempt
+// number, and the NumResults decoded. The start of the body is logged when
+// it can't be decoded.
+func WithLogger(l *slog.Logger) OptionFunc {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithLogLevels changes the levels the Client logs at from DefaultLogLevels
+func WithLogLevels(levels LogLevels) OptionFunc {
+	return func(c *Client) {
+		c.logLevels = levels
+	}
+}
+
+type attemptKey struct{}
+
+// withAttempt records the attempt number of a call for logging.
+// The Client doesn't retry yet, so calls are logged as attempt 1.
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attemptFrom returns the attempt number of a call, 1 if not recorded
+func attemptFrom(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+
+	return 1
+}
+
+// logResponse logs a call, which failed if err isn't nil
+func (c *Client) logResponse(ctx context.Context, link string, resp *http.Response, err error, d time.Duration) {
+	level := c.logLevels.Response
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpointFrom(ctx)),
+		slog.String("url", redact(link)),
+		slog.Int("attempt", attemptFrom(ctx)),
+		slog.Duration("duration", d),
+	}
+	if err != nil {
+		level = c.logLevels.Failure
+		attrs = append(attrs, slog.Any("error", err))
+	} else {
+		if resp.StatusCode >= 400 {
+			level = c.logLevels.Failure
+		}
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	}
+
+	c.logger.LogAttrs(ctx, level, "books: response", attrs...)
+}
+
+// logDecode logs the decoding of a response into v, with the start
+// of the body if it failed
+func (c *Client) logDecode(ctx context.Context, v interface{}, body *snippet, err error) {
+	endpoint := slog.String("endpoint", endpointFrom(ctx))
+	if err != nil {
+		c.logger.LogAttrs(ctx, c.logLevels.Failure, "books: decoding failed", endpoint,
+			slog.Any("error", err), slog.String("body", string(body.b)))
+		return
+	}
+
+	attrs := []slog.Attr{endpoint}
+	if n, ok := numResults(v); ok {
+		attrs = append(attrs, slog.Int("num_results", n))
+	}
+	c.logger.LogAttrs(ctx, c.logLevels.Response, "books: decoded", attrs...)
+}
+
+// numResults returns the NumResults field of a response
+func numResults(v interface{}) (int, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return 0, false
+	}
+	f := rv.FieldByName("NumResults")
+	if f.Kind() != reflect.Int {
+		return 0, false
+	}
+
+	return int(f.Int()), true
+}
+
+// snippet keeps the first snippetSize bytes written to it
+type snippet struct {
+	b []byte
+}
+
+func (s *snippet) Write(p []byte) (int, error) {
+	if n := snippetSize - len(s.b); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		s.b = append(s.b, p[:n]...)
+	}
+
+	return len(p), nil
+}
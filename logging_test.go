@@ -0,0 +1,107 @@
+package books
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// logRecords decodes the records written by a slog JSON handler
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var r map[string]interface{}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+
+	return records
+}
+
+func TestLogger(t *testing.T) {
+	mc := &MockClient{
+		MockDo: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"status":"OK","num_results":2,"results":[]}`)),
+			}, nil
+		},
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient("secret", WithHTTPClient(mc), WithLogger(logger))
+	if _, err := c.GetBestSellersListNames(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %v", len(records), records)
+	}
+	resp, decoded := records[0], records[1]
+	if resp["msg"] != "books: response" || resp["level"] != "DEBUG" {
+		t.Errorf("got %v, want a debug response record", resp)
+	}
+	if resp["endpoint"] != NamesEndpoint || resp["status"] != 200.0 || resp["attempt"] != 1.0 {
+		t.Errorf("got %v, want endpoint %s, status 200 and attempt 1", resp, NamesEndpoint)
+	}
+	if url := resp["url"].(string); strings.Contains(url, "secret") {
+		t.Errorf("logged url %q has the api key", url)
+	}
+	if decoded["msg"] != "books: decoded" || decoded["num_results"] != 2.0 {
+		t.Errorf("got %v, want a decoded record with 2 results", decoded)
+	}
+}
+
+func TestLoggerDecodeFailure(t *testing.T) {
+	body := "<html>" + strings.Repeat("x", 2*snippetSize) + "</html>"
+	mc := &MockClient{
+		MockDo: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			}, nil
+		},
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	levels := LogLevels{Response: slog.LevelDebug, Failure: slog.LevelError}
+	c := NewClient("apikey", WithHTTPClient(mc), WithLogger(logger), WithLogLevels(levels))
+	if _, err := c.GetOverview(nil); err == nil {
+		t.Fatal("expected a decoding error")
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %v", len(records), records)
+	}
+	if records[0]["level"] != "ERROR" || records[0]["status"] != 503.0 {
+		t.Errorf("got %v, want an error response record with status 503", records[0])
+	}
+	failed := records[1]
+	if failed["msg"] != "books: decoding failed" || failed["endpoint"] != OverviewEndpoint {
+		t.Errorf("got %v, want a decoding failure on %s", failed, OverviewEndpoint)
+	}
+	if snip := failed["body"].(string); snip != body[:snippetSize] {
+		t.Errorf("logged body %q, want the first %d bytes", snip, snippetSize)
+	}
+}
+
+func TestAttemptFrom(t *testing.T) {
+	ctx := context.Background()
+	if got := attemptFrom(ctx); got != 1 {
+		t.Errorf("attemptFrom(ctx) == %d, want 1", got)
+	}
+	if got := attemptFrom(withAttempt(ctx, 3)); got != 3 {
+		t.Errorf("attemptFrom(withAttempt(ctx, 3)) == %d, want 3", got)
+	}
+}
@@ -0,0 +1,201 @@
+// Package metrics exports the calls a books.Client makes in the
+// Prometheus text format, without depending on the Prometheus client.
+//
+//	m := metrics.NewPrometheus()
+//	c := books.NewClient(apiKey, books.WithObserver(m))
+//	http.Handle("/metrics", m)
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the request duration histogram
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// phases are the httptrace phases exported
+var phases = []string{"dns", "connect", "tls", "first_byte"}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Prometheus is a books.Observer collecting per endpoint metrics,
+// served in the Prometheus text format by ServeHTTP
+type Prometheus struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	inFlight  map[string]int64
+	requests  map[[2]string]uint64
+	errors    map[[2]string]uint64
+	bytes     map[string]uint64
+	durations map[string]*histogram
+	phaseSums map[[2]string]float64
+}
+
+// NewPrometheus constructs a Prometheus observer using DefaultBuckets
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		buckets:   DefaultBuckets,
+		inFlight:  make(map[string]int64),
+		requests:  make(map[[2]string]uint64),
+		errors:    make(map[[2]string]uint64),
+		bytes:     make(map[string]uint64),
+		durations: make(map[string]*histogram),
+		phaseSums: make(map[[2]string]float64),
+	}
+}
+
+// OnRequestStart implements books.Observer
+func (p *Prometheus) OnRequestStart(info books.RequestInfo) {
+	p.mu.Lock()
+	p.inFlight[info.Endpoint]++
+	p.mu.Unlock()
+}
+
+// OnRequestEnd implements books.Observer
+func (p *Prometheus) OnRequestEnd(info books.RequestInfo, res books.RequestResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ep := info.Endpoint
+	p.inFlight[ep]--
+	status := "error"
+	if res.Status != 0 {
+		status = strconv.Itoa(res.Status)
+	}
+	p.requests[[2]string{ep, status}]++
+	if res.ErrorClass != "" {
+		p.errors[[2]string{ep, res.ErrorClass}]++
+	}
+	p.bytes[ep] += uint64(res.BytesRead)
+
+	h, ok := p.durations[ep]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(p.buckets))}
+		p.durations[ep] = h
+	}
+	secs := res.Duration.Seconds()
+	for i, le := range p.buckets {
+		if secs <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += secs
+	h.count++
+
+	p.phaseSums[[2]string{ep, "dns"}] += res.Trace.DNS.Seconds()
+	p.phaseSums[[2]string{ep, "connect"}] += res.Trace.Connect.Seconds()
+	p.phaseSums[[2]string{ep, "tls"}] += res.Trace.TLS.Seconds()
+	p.phaseSums[[2]string{ep, "first_byte"}] += res.Trace.FirstByte.Seconds()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	header(&b, "nytbooks_requests_in_flight", "gauge", "Requests to the Books API in flight.")
+	for _, ep := range sortedKeys(p.inFlight) {
+		fmt.Fprintf(&b, "nytbooks_requests_in_flight{endpoint=%q} %d\n", ep, p.inFlight[ep])
+	}
+
+	header(&b, "nytbooks_requests_total", "counter", "Requests to the Books API by endpoint and status code.")
+	for _, k := range sortedPairs(p.requests) {
+		fmt.Fprintf(&b, "nytbooks_requests_total{endpoint=%q,status=%q} %d\n", k[0], k[1], p.requests[k])
+	}
+
+	header(&b, "nytbooks_request_errors_total", "counter", "Failed requests to the Books API by endpoint and error class.")
+	for _, k := range sortedPairs(p.errors) {
+		fmt.Fprintf(&b, "nytbooks_request_errors_total{endpoint=%q,class=%q} %d\n", k[0], k[1], p.errors[k])
+	}
+
+	header(&b, "nytbooks_response_bytes_total", "counter", "Response bytes read from the Books API.")
+	for _, ep := range sortedKeys(p.bytes) {
+		fmt.Fprintf(&b, "nytbooks_response_bytes_total{endpoint=%q} %d\n", ep, p.bytes[ep])
+	}
+
+	header(&b, "nytbooks_request_duration_seconds", "histogram", "Duration of requests to the Books API, body included.")
+	for _, ep := range sortedKeys(p.durations) {
+		h := p.durations[ep]
+		for i, le := range p.buckets {
+			fmt.Fprintf(&b, "nytbooks_request_duration_seconds_bucket{endpoint=%q,le=%q} %d\n", ep, formatFloat(le), h.counts[i])
+		}
+		fmt.Fprintf(&b, "nytbooks_request_duration_seconds_bucket{endpoint=%q,le=\"+Inf\"} %d\n", ep, h.count)
+		fmt.Fprintf(&b, "nytbooks_request_duration_seconds_sum{endpoint=%q} %s\n", ep, formatFloat(h.sum))
+		fmt.Fprintf(&b, "nytbooks_request_duration_seconds_count{endpoint=%q} %d\n", ep, h.count)
+	}
+
+	header(&b, "nytbooks_request_phase_seconds_total", "counter", "Time spent in each phase of requests to the Books API.")
+	for _, ep := range sortedKeys(p.durations) {
+		for _, phase := range phases {
+			fmt.Fprintf(&b, "nytbooks_request_phase_seconds_total{endpoint=%q,phase=%q} %s\n", ep, phase, formatFloat(p.phaseSums[[2]string{ep, phase}]))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func header(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]int64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]uint64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]*histogram:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedPairs(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	return keys
+}
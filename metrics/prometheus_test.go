@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func TestPrometheus(t *testing.T) {
+	p := NewPrometheus()
+	info := books.RequestInfo{Endpoint: books.OverviewEndpoint}
+	p.OnRequestStart(info)
+	p.OnRequestEnd(info, books.RequestResult{Status: 200, Duration: 200 * time.Millisecond, BytesRead: 1024})
+	p.OnRequestStart(info)
+	p.OnRequestEnd(info, books.RequestResult{Duration: 3 * time.Second, ErrorClass: books.ClassTimeout})
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+
+	for _, want := range []string{
+		`nytbooks_requests_in_flight{endpoint="/lists/overview.json"} 0`,
+		`nytbooks_requests_total{endpoint="/lists/overview.json",status="200"} 1`,
+		`nytbooks_requests_total{endpoint="/lists/overview.json",status="error"} 1`,
+		`nytbooks_request_errors_total{endpoint="/lists/overview.json",class="timeout"} 1`,
+		`nytbooks_response_bytes_total{endpoint="/lists/overview.json"} 1024`,
+		`nytbooks_request_duration_seconds_bucket{endpoint="/lists/overview.json",le="0.25"} 1`,
+		`nytbooks_request_duration_seconds_bucket{endpoint="/lists/overview.json",le="5"} 2`,
+		`nytbooks_request_duration_seconds_bucket{endpoint="/lists/overview.json",le="+Inf"} 2`,
+		`nytbooks_request_duration_seconds_sum{endpoint="/lists/overview.json"} 3.2`,
+		`nytbooks_request_duration_seconds_count{endpoint="/lists/overview.json"} 2`,
+		`# TYPE nytbooks_request_duration_seconds histogram`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics don't contain %q:\n%s", want, out)
+		}
+	}
+}
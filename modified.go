@@ -0,0 +1,191 @@
+package books
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNotModified is returned by FetchIfChanged when a list's LastModified
+// is the same as when it was last fetched
+var ErrNotModified = errors.New("books: list not modified")
+
+// ModifiedState remembers the LastModified last seen for each list
+type ModifiedState interface {
+	// LastModified returns the LastModified stored for key, or "" if none is
+	LastModified(key string) (string, error)
+	SetLastModified(key, lastModified string) error
+}
+
+// FetchIfChanged gets a list by date like GetBestSellersListByDate, unless
+// its LastModified is the one state holds for it, in which case it returns
+// ErrNotModified without decoding the books. The whole list is still
+// downloaded, the API having no conditional requests, so this saves
+// decoding but no bandwidth. state is updated with the LastModified of
+// the lists fetched.
+func (c *Client) FetchIfChanged(date, listName string, qp QueryParam, state ModifiedState) (*ListByDate, error) {
+	key := modifiedKey(date, listName, qp)
+	last, err := state.LastModified(key)
+	if err != nil {
+		return nil, err
+	}
+
+	URL, err := c.makeLink(fmt.Sprintf(ListsByDateEndpoint, date, listName), qp)
+	if err != nil {
+		return nil, err
+	}
+	ctx := withEndpoint(context.Background(), ListsByDateEndpoint)
+	resp, err := c.get(ctx, URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if last != "" && peekLastModified(data) == last {
+		return nil, ErrNotModified
+	}
+
+	var list ListByDate
+	if err := c.decode(ctx, bytes.NewReader(data), &list); err != nil {
+		return nil, err
+	}
+	if list.LastModified != "" && list.LastModified != last {
+		if err := state.SetLastModified(key, list.LastModified); err != nil {
+			return nil, err
+		}
+	}
+
+	return &list, nil
+}
+
+// modifiedKey identifies a list request in a ModifiedState
+func modifiedKey(date, listName string, qp QueryParam) string {
+	key := listName + "/" + date
+	if len(qp) > 0 {
+		key += "?" + qp.String()
+	}
+
+	return key
+}
+
+// errFound stops streamObject once the wanted key is decoded
+var errFound = errors.New("found")
+
+// peekLastModified returns the top level last_modified of a response,
+// decoding nothing else than the keys preceding it
+func peekLastModified(data []byte) string {
+	var modified string
+	dec := json.NewDecoder(bytes.NewReader(data))
+	streamObject(dec, func(key string) error {
+		if key != "last_modified" {
+			return skip(dec)
+		}
+		if err := dec.Decode(&modified); err != nil {
+			return err
+		}
+		return errFound
+	})
+
+	return modified
+}
+
+// MemoryState is a ModifiedState held in memory
+type MemoryState struct {
+	mu    sync.Mutex
+	state map[string]string
+}
+
+// NewMemoryState constructs an empty MemoryState
+func NewMemoryState() *MemoryState {
+	return &MemoryState{state: make(map[string]string)}
+}
+
+// LastModified implements ModifiedState
+func (s *MemoryState) LastModified(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state[key], nil
+}
+
+// SetLastModified implements ModifiedState
+func (s *MemoryState) SetLastModified(key, lastModified string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[key] = lastModified
+
+	return nil
+}
+
+// FileState is a ModifiedState persisted to a JSON file,
+// so that it survives restarts of a poller
+type FileState struct {
+	name string
+
+	mu    sync.Mutex
+	state map[string]string
+}
+
+// NewFileState loads the state saved in the file name,
+// starting empty if the file doesn't exist yet
+func NewFileState(name string) (*FileState, error) {
+	s := &FileState{name: name, state: make(map[string]string)}
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("books: state file %s: %v", name, err)
+	}
+
+	return s, nil
+}
+
+// LastModified implements ModifiedState
+func (s *FileState) LastModified(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state[key], nil
+}
+
+// SetLastModified implements ModifiedState, saving the file
+func (s *FileState) SetLastModified(key, lastModified string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[key] = lastModified
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write then rename so that a crash doesn't leave a truncated file
+	tmp, err := ioutil.TempFile(filepath.Dir(s.name), filepath.Base(s.name)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.name)
+}
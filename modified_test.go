@@ -0,0 +1,92 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchIfChanged(t *testing.T) {
+	listData, err := ioutil.ReadFile("testdata/list_by_date.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	jsonData := listData
+	calls := 0
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			calls++
+			body := ioutil.NopCloser(bytes.NewReader(jsonData))
+
+			return &http.Response{StatusCode: http.StatusOK, Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+	state := NewMemoryState()
+
+	list, err := c.FetchIfChanged("current", "trade-fiction-paperback", nil, state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Results.ListName != "Trade Fiction Paperback" {
+		t.Errorf("got list %q, want Trade Fiction Paperback", list.Results.ListName)
+	}
+	if got, _ := state.LastModified("trade-fiction-paperback/current"); got != list.LastModified {
+		t.Errorf("state holds %q, want %q", got, list.LastModified)
+	}
+
+	// an unchanged list isn't decoded, so its broken results don't matter
+	jsonData = []byte(`{"status": "OK", "last_modified": "2015-12-25T13:05:20-05:00", "results": "broken"}`)
+	if _, err := c.FetchIfChanged("current", "trade-fiction-paperback", nil, state); !errors.Is(err, ErrNotModified) {
+		t.Errorf("got error %v, want ErrNotModified", err)
+	}
+
+	jsonData = bytes.Replace(listData, []byte("2015-12-25T13:05:20-05:00"), []byte("2016-01-01T13:05:20-05:00"), 1)
+	if _, err := c.FetchIfChanged("current", "trade-fiction-paperback", nil, state); err != nil {
+		t.Errorf("got error %v for a changed list", err)
+	}
+	if got, _ := state.LastModified("trade-fiction-paperback/current"); got != "2016-01-01T13:05:20-05:00" {
+		t.Errorf("state holds %q after the list changed", got)
+	}
+	if calls != 3 {
+		t.Errorf("made %d calls, want 3", calls)
+	}
+}
+
+func TestFileState(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "state.json")
+	s, err := NewFileState(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.SetLastModified("hardcover-fiction/current", "2015-12-25T13:05:20-05:00"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err = NewFileState(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := s.LastModified("hardcover-fiction/current"); got != "2015-12-25T13:05:20-05:00" {
+		t.Errorf("reloaded state holds %q", got)
+	}
+	if got, _ := s.LastModified("picture-books/current"); got != "" {
+		t.Errorf("got %q for an unknown list, want none", got)
+	}
+}
+
+func TestPeekLastModified(t *testing.T) {
+	cases := map[string]string{
+		`{"status":"OK","results":[1,2],"last_modified":"x"}`: "x",
+		`{"status":"OK","results":[]}`:                        "",
+		`not json`:                                            "",
+	}
+	for data, want := range cases {
+		if got := peekLastModified([]byte(data)); got != want {
+			t.Errorf("peekLastModified(%s) == %q, want %q", data, got, want)
+		}
+	}
+}
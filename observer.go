@@ -0,0 +1,272 @@
+package books
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Observer is notified of every call the Client makes to the API,
+// for metrics or tracing. It must be safe for concurrent use.
+type Observer interface {
+	OnRequestStart(RequestInfo)
+	// OnRequestEnd is called once the response body is closed,
+	// or when the request failed. A response that couldn't be decoded
+	// ends with the decoding error, classified as ClassDecode, except
+	// with WithRequestCoalescing where calls end before being decoded.
+	OnRequestEnd(RequestInfo, RequestResult)
+}
+
+// RequestInfo describes a call to the API
+type RequestInfo struct {
+	// Endpoint is the endpoint called, as in endpoints.go,
+	// e.g. ListsByDateEndpoint
+	Endpoint string
+	// URL is the URL called, with the value of the api key
+	// replaced by REDACTED
+	URL   string
+	Start time.Time
+}
+
+// RequestResult is the outcome of a call to the API
+type RequestResult struct {
+	Status    int
+	Duration  time.Duration
+	BytesRead int64
+	Err       error
+	// ErrorClass is a coarse classification of the error, or of a status
+	// over 400, empty on success. See ClassifyError.
+	ErrorClass string
+	Trace      Timings
+}
+
+// Timings break the time to first byte down with httptrace. They are only
+// filled when the Doer is an *http.Client, and are zero on reused connections.
+type Timings struct {
+	DNS       time.Duration
+	Connect   time.Duration
+	TLS       time.Duration
+	FirstByte time.Duration
+}
+
+// Error classes returned by ClassifyError
+const (
+	ClassCanceled    = "canceled"
+	ClassTimeout     = "timeout"
+	ClassNetwork     = "network"
+	ClassTooLarge    = "too_large"
+	ClassDecode      = "decode"
+	ClassClientError = "client_error"
+	ClassServerError = "server_error"
+	ClassOther       = "other"
+)
+
+// ClassifyError classifies a call's error, or its status code if err is nil.
+// It returns an empty string for successful calls.
+func ClassifyError(err error, status int) string {
+	if err == nil {
+		switch {
+		case status >= 500:
+			return ClassServerError
+		case status >= 400:
+			return ClassClientError
+		}
+		return ""
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ClassCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClassTimeout
+	case errors.Is(err, ErrResponseTooLarge):
+		return ClassTooLarge
+	case isDecodeError(err):
+		return ClassDecode
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return ClassTimeout
+		}
+		return ClassNetwork
+	}
+
+	return ClassOther
+}
+
+// isDecodeError reports whether err comes from decoding a malformed
+// or truncated payload, or one not matching its model
+func isDecodeError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// WithObserver makes the Client report its calls to o
+func WithObserver(o Observer) OptionFunc {
+	return func(c *Client) {
+		c.observer = o
+	}
+}
+
+type endpointKey struct{}
+
+// withEndpoint records the endpoint being called for observers
+func withEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+func endpointFrom(ctx context.Context) string {
+	endpoint, _ := ctx.Value(endpointKey{}).(string)
+	return endpoint
+}
+
+// redact removes the api key from a URL
+func redact(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	q := u.Query()
+	if q.Get("api-key") != "" {
+		q.Set("api-key", "REDACTED")
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
+
+// tracer collects httptrace timings
+type tracer struct {
+	mu                            sync.Mutex
+	start                         time.Time
+	dnsStart, connStart, tlsStart time.Time
+	timings                       Timings
+}
+
+func (t *tracer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.since(&t.timings.DNS, t.dnsStart) },
+		ConnectStart: func(string, string) {
+			t.set(&t.connStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.since(&t.timings.Connect, t.connStart)
+		},
+		TLSHandshakeStart: func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.since(&t.timings.TLS, t.tlsStart)
+		},
+		GotFirstResponseByte: func() { t.since(&t.timings.FirstByte, t.start) },
+	}
+}
+
+func (t *tracer) set(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *tracer) since(d *time.Duration, from time.Time) {
+	t.mu.Lock()
+	*d = time.Since(from)
+	t.mu.Unlock()
+}
+
+func (t *tracer) get() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.timings
+}
+
+// observedBody reports the end of a call to the observer once closed
+type observedBody struct {
+	io.ReadCloser
+	once sync.Once
+	n    int64
+	err  error
+	end  func(bytesRead int64, err error)
+}
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.end(b.n, b.err) })
+
+	return err
+}
+
+// failDecode records a decoding error, unless reading failed first
+func (b *observedBody) failDecode(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// observation is a call being reported to the Client's observer
+type observation struct {
+	observer Observer
+	info     RequestInfo
+	tracer   *tracer
+}
+
+// startObservation reports the start of a call, returning req with tracing
+func (c *Client) startObservation(req *http.Request) (*http.Request, *observation) {
+	o := &observation{
+		observer: c.observer,
+		info: RequestInfo{
+			Endpoint: endpointFrom(req.Context()),
+			URL:      redact(req.URL.String()),
+			Start:    time.Now(),
+		},
+	}
+	o.tracer = &tracer{start: o.info.Start}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), o.tracer.trace()))
+	o.observer.OnRequestStart(o.info)
+
+	return req, o
+}
+
+// fail reports a call that got no response
+func (o *observation) fail(err error) {
+	o.observer.OnRequestEnd(o.info, RequestResult{
+		Duration:   time.Since(o.info.Start),
+		Err:        err,
+		ErrorClass: ClassifyError(err, 0),
+		Trace:      o.tracer.get(),
+	})
+}
+
+// wrap makes the response's body report the end of the call once closed
+func (o *observation) wrap(resp *http.Response) {
+	resp.Body = &observedBody{ReadCloser: resp.Body, end: func(n int64, readErr error) {
+		o.observer.OnRequestEnd(o.info, RequestResult{
+			Status:     resp.StatusCode,
+			Duration:   time.Since(o.info.Start),
+			BytesRead:  n,
+			Err:        readErr,
+			ErrorClass: ClassifyError(readErr, resp.StatusCode),
+			Trace:      o.tracer.get(),
+		})
+	}}
+}
@@ -0,0 +1,182 @@
+package books
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordingObserver struct {
+	mu      sync.Mutex
+	started []RequestInfo
+	ended   []RequestResult
+}
+
+func (o *recordingObserver) OnRequestStart(info RequestInfo) {
+	o.mu.Lock()
+	o.started = append(o.started, info)
+	o.mu.Unlock()
+}
+
+func (o *recordingObserver) OnRequestEnd(info RequestInfo, res RequestResult) {
+	o.mu.Lock()
+	o.ended = append(o.ended, res)
+	o.mu.Unlock()
+}
+
+func TestObserver(t *testing.T) {
+	body := `{"status":"OK","num_results":0,"results":[]}`
+	mc := &MockClient{
+		MockDo: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		},
+	}
+	o := &recordingObserver{}
+	c := NewClient("secret", WithHTTPClient(mc), WithObserver(o))
+	if _, err := c.GetBestSellersListNames(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(o.started) != 1 || len(o.ended) != 1 {
+		t.Fatalf("got %d starts and %d ends, want 1 each", len(o.started), len(o.ended))
+	}
+	info, res := o.started[0], o.ended[0]
+	if info.Endpoint != NamesEndpoint {
+		t.Errorf("Endpoint == %q, want %q", info.Endpoint, NamesEndpoint)
+	}
+	if strings.Contains(info.URL, "secret") || !strings.Contains(info.URL, "api-key=REDACTED") {
+		t.Errorf("URL %q isn't redacted", info.URL)
+	}
+	if res.Status != http.StatusOK || res.BytesRead != int64(len(body)) || res.ErrorClass != "" {
+		t.Errorf("got result %+v, want status 200, %d bytes and no error", res, len(body))
+	}
+}
+
+func TestObserverEndsOnClose(t *testing.T) {
+	mc := &MockClient{
+		MockDo: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader("jetfuel")),
+			}, nil
+		},
+	}
+	o := &recordingObserver{}
+	c := NewClient("apikey", WithHTTPClient(mc), WithObserver(o))
+	resp, err := c.get(withEndpoint(context.Background(), OverviewEndpoint), "someplace.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(o.ended) != 0 {
+		t.Fatal("OnRequestEnd called before the body was closed")
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+	if len(o.ended) != 1 {
+		t.Errorf("OnRequestEnd called %d times, want 1", len(o.ended))
+	}
+}
+
+func TestObserverErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		do    func(*http.Request) (*http.Response, error)
+		opts  []OptionFunc
+		class string
+	}{
+		{
+			name: "network",
+			do: func(*http.Request) (*http.Response, error) {
+				return nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+			},
+			class: ClassNetwork,
+		},
+		{
+			name: "server error",
+			do: func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusBadGateway,
+					Body:       ioutil.NopCloser(strings.NewReader("{}")),
+				}, nil
+			},
+			class: ClassServerError,
+		},
+		{
+			name: "malformed",
+			do: func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"status": "OK", "results": [`)),
+				}, nil
+			},
+			class: ClassDecode,
+		},
+		{
+			name: "wrong type",
+			do: func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"status": "OK", "num_results": "two"}`)),
+				}, nil
+			},
+			class: ClassDecode,
+		},
+		{
+			name: "too large",
+			do: func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"status":"OK","results":[]}`)),
+				}, nil
+			},
+			opts:  []OptionFunc{WithMaxResponseSize(4)},
+			class: ClassTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &recordingObserver{}
+			opts := append([]OptionFunc{WithHTTPClient(&MockClient{MockDo: tc.do}), WithObserver(o)}, tc.opts...)
+			c := NewClient("apikey", opts...)
+			c.GetBestSellersListNames()
+
+			if len(o.ended) != 1 {
+				t.Fatalf("OnRequestEnd called %d times, want 1", len(o.ended))
+			}
+			if got := o.ended[0].ErrorClass; got != tc.class {
+				t.Errorf("ErrorClass == %q, want %q", got, tc.class)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		want   string
+	}{
+		{nil, 200, ""},
+		{nil, 404, ClassClientError},
+		{nil, 503, ClassServerError},
+		{context.Canceled, 0, ClassCanceled},
+		{context.DeadlineExceeded, 0, ClassTimeout},
+		{ErrResponseTooLarge, 200, ClassTooLarge},
+		{errors.New("boom"), 0, ClassOther},
+	}
+	for _, tc := range cases {
+		if got := ClassifyError(tc.err, tc.status); got != tc.want {
+			t.Errorf("ClassifyError(%v, %d) == %q, want %q", tc.err, tc.status, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+// Package opds serves the best sellers lists as an OPDS 1.2 catalog,
+// for e-reader apps.
+//
+// The root of the catalog is a navigation feed of the lists, each list
+// being an acquisition feed of its current edition:
+//
+//	http.Handle("/opds/", opds.NewHandler(c, opds.WithPrefix("/opds")))
+package opds
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/internal/singleflight"
+)
+
+// errNotFound is returned for lists and pages the API doesn't have
+var errNotFound = errors.New("opds: not found")
+
+// Feed types
+const (
+	NavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	AcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+)
+
+// PageSize is the number of books the API returns per offset
+const PageSize = 20
+
+// Source is what the Handler serves, implemented by *books.Client
+type Source interface {
+	GetBestSellersListNames() (*books.Names, error)
+	GetBestSellersListByDate(date, listName string, qp books.QueryParam) (*books.ListByDate, error)
+}
+
+// Option configures a Handler
+type Option func(*Handler)
+
+// WithPrefix sets the path the Handler is mounted on, e.g. "/opds"
+func WithPrefix(prefix string) Option {
+	return func(h *Handler) {
+		h.prefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithTitle sets the title of the catalog
+func WithTitle(title string) Option {
+	return func(h *Handler) {
+		h.title = title
+	}
+}
+
+// WithTTL sets how long feeds are cached, an hour by default
+func WithTTL(ttl time.Duration) Option {
+	return func(h *Handler) {
+		h.ttl = ttl
+	}
+}
+
+type cached struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Handler serves the OPDS catalog
+type Handler struct {
+	source Source
+	prefix string
+	title  string
+	ttl    time.Duration
+	now    func() time.Time
+
+	flight singleflight.Group
+	mu     sync.Mutex
+	cache  map[string]cached
+}
+
+// NewHandler constructs a Handler serving the lists of source
+func NewHandler(source Source, options ...Option) *Handler {
+	h := &Handler{
+		source: source,
+		title:  "New York Times Best Sellers",
+		ttl:    time.Hour,
+		now:    time.Now,
+		cache:  make(map[string]cached),
+	}
+	for _, option := range options {
+		option(h)
+	}
+
+	return h
+}
+
+// ServeHTTP serves the navigation feed on the root path, and the
+// acquisition feed of a list on /lists/{list_name_encoded}?page=N.
+// Only the lists of the navigation feed and their pages are found,
+// and API errors are not passed on, as they can carry the api key.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, h.prefix)
+	var key, contentType string
+	var build func() (interface{}, error)
+	switch {
+	case path == "" || path == "/":
+		key, contentType = "/", NavigationType
+		build = func() (interface{}, error) { return h.navigation() }
+	case strings.HasPrefix(path, "/lists/") && !strings.Contains(path[len("/lists/"):], "/"):
+		name := path[len("/lists/"):]
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 1 {
+				http.Error(w, "invalid page", http.StatusBadRequest)
+				return
+			}
+			page = n
+		}
+		key, contentType = fmt.Sprintf("%s?page=%d", path, page), AcquisitionType
+		build = func() (interface{}, error) { return h.acquisition(name, page) }
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	feed, err := h.get(key, build)
+	if err == errNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(feed.([]byte))
+}
+
+// get returns the cached value for key, building it on a miss.
+// Concurrent misses share a single build.
+func (h *Handler) get(key string, build func() (interface{}, error)) (interface{}, error) {
+	h.mu.Lock()
+	c, ok := h.cache[key]
+	h.mu.Unlock()
+	if ok && h.now().Before(c.expires) {
+		return c.value, nil
+	}
+
+	v, err, _ := h.flight.Do(key, func() (interface{}, error) {
+		v, err := build()
+		if err != nil {
+			return nil, err
+		}
+		h.mu.Lock()
+		h.sweep()
+		h.cache[key] = cached{value: v, expires: h.now().Add(h.ttl)}
+		h.mu.Unlock()
+		return v, nil
+	})
+
+	return v, err
+}
+
+// sweep deletes the expired entries of the cache, h.mu being held.
+// With the names and pages checked against the API, what's left is
+// bounded by the number of pages of all the lists.
+func (h *Handler) sweep() {
+	now := h.now()
+	for key, c := range h.cache {
+		if !now.Before(c.expires) {
+			delete(h.cache, key)
+		}
+	}
+}
+
+// names returns the cached list names
+func (h *Handler) names() (*books.Names, error) {
+	v, err := h.get("names", func() (interface{}, error) {
+		names, err := h.source.GetBestSellersListNames()
+		if err != nil {
+			return nil, err
+		}
+		if names.Status != "OK" {
+			return nil, fmt.Errorf("opds: list names: status %q", names.Status)
+		}
+		return names, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*books.Names), nil
+}
+
+// firstPage returns the cached first page of the current edition
+// of a list, errNotFound if the list isn't one of the names
+func (h *Handler) firstPage(name string) (*books.ListByDate, error) {
+	names, err := h.names()
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, res := range names.Results {
+		if res.ListNameEncoded == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errNotFound
+	}
+
+	v, err := h.get("list:"+name, func() (interface{}, error) {
+		return h.list(name, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*books.ListByDate), nil
+}
+
+type feed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	DC      string   `xml:"xmlns:dc,attr,omitempty"`
+	OPDS    string   `xml:"xmlns:opds,attr"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Author  *person  `xml:"author"`
+	Links   []link   `xml:"link"`
+	Entries []entry  `xml:"entry"`
+}
+
+type link struct {
+	Href  string `xml:"href,attr"`
+	Rel   string `xml:"rel,attr,omitempty"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+type person struct {
+	Name string `xml:"name"`
+}
+
+type text struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type entry struct {
+	ID          string   `xml:"id"`
+	Title       string   `xml:"title"`
+	Updated     string   `xml:"updated"`
+	Authors     []person `xml:"author"`
+	Identifiers []string `xml:"dc:identifier"`
+	Publisher   string   `xml:"dc:publisher,omitempty"`
+	Issued      string   `xml:"dc:issued,omitempty"`
+	Summary     *text    `xml:"summary"`
+	Content     *text    `xml:"content"`
+	Links       []link   `xml:"link"`
+}
+
+// navigation builds the navigation feed of the lists
+func (h *Handler) navigation() ([]byte, error) {
+	names, err := h.names()
+	if err != nil {
+		return nil, err
+	}
+
+	self := h.prefix + "/"
+	f := feed{
+		OPDS:    "http://opds-spec.org/2010/catalog",
+		ID:      "urn:nytbooks:lists",
+		Title:   h.title,
+		Updated: atomDate(h.now()),
+		Author:  &person{Name: "The New York Times"},
+		Links: []link{
+			{Href: self, Rel: "self", Type: NavigationType},
+			{Href: self, Rel: "start", Type: NavigationType},
+		},
+	}
+	for _, res := range names.Results {
+		updated := h.now()
+		if t, err := time.Parse(books.DateLayout, res.NewestPublishedDate); err == nil {
+			updated = t
+		}
+		f.Entries = append(f.Entries, entry{
+			ID:      "urn:nytbooks:list:" + res.ListNameEncoded,
+			Title:   res.DisplayName,
+			Updated: atomDate(updated),
+			Content: &text{Type: "text", Value: fmt.Sprintf("%s list, published %s to %s",
+				strings.Title(strings.ToLower(res.Updated)), res.OldestPublishedDate, res.NewestPublishedDate)},
+			Links: []link{{Href: h.listHref(res.ListNameEncoded, 1), Rel: "subsection", Type: AcquisitionType}},
+		})
+	}
+
+	return encode(f)
+}
+
+// list gets the current edition of a list. A client without
+// WithStatusErrors decodes error payloads, which are errors
+// here rather than empty lists to be cached.
+func (h *Handler) list(name string, qp books.QueryParam) (*books.ListByDate, error) {
+	list, err := h.source.GetBestSellersListByDate("current", name, qp)
+	if err != nil {
+		return nil, err
+	}
+	if list.Status != "OK" {
+		return nil, fmt.Errorf("opds: list %s: status %q", name, list.Status)
+	}
+
+	return list, nil
+}
+
+// acquisition builds the acquisition feed of a page of a list,
+// errNotFound past the last page
+func (h *Handler) acquisition(name string, page int) ([]byte, error) {
+	list, err := h.firstPage(name)
+	if err != nil {
+		return nil, err
+	}
+	offset := (page - 1) * PageSize
+	if offset > 0 {
+		if offset >= list.NumResults {
+			return nil, errNotFound
+		}
+		qp := books.QueryParam{"offset": strconv.Itoa(offset)}
+		if list, err = h.list(name, qp); err != nil {
+			return nil, err
+		}
+	}
+
+	updated := h.now()
+	if t, err := time.Parse(time.RFC3339, list.LastModified); err == nil {
+		updated = t
+	}
+	f := feed{
+		DC:      "http://purl.org/dc/terms/",
+		OPDS:    "http://opds-spec.org/2010/catalog",
+		ID:      "urn:nytbooks:list:" + name,
+		Title:   list.Results.DisplayName,
+		Updated: atomDate(updated),
+		Links: []link{
+			{Href: h.listHref(name, page), Rel: "self", Type: AcquisitionType},
+			{Href: h.prefix + "/", Rel: "start", Type: NavigationType},
+			{Href: h.prefix + "/", Rel: "up", Type: NavigationType},
+		},
+	}
+	if page > 1 {
+		f.Links = append(f.Links, link{Href: h.listHref(name, page-1), Rel: "previous", Type: AcquisitionType})
+	}
+	if offset+len(list.Results.Books) < list.NumResults {
+		f.Links = append(f.Links, link{Href: h.listHref(name, page+1), Rel: "next", Type: AcquisitionType})
+	}
+
+	for _, b := range list.Results.Books {
+		f.Entries = append(f.Entries, bookEntry(b, list.Results.PublishedDate, updated))
+	}
+
+	return encode(f)
+}
+
+func bookEntry(b books.ListBook, published string, updated time.Time) entry {
+	e := entry{
+		ID:        "urn:nytbooks:book:" + b.PrimaryISBN13,
+		Title:     b.Title,
+		Updated:   atomDate(updated),
+		Publisher: b.Publisher,
+		Issued:    published,
+		Content:   &text{Type: "text", Value: fmt.Sprintf("#%d, %s on the list", b.Rank, weeks(b.WeeksOnList))},
+	}
+	if b.PrimaryISBN13 != "" {
+		e.ID = "urn:isbn:" + b.PrimaryISBN13
+	}
+	for _, isbn := range isbns(b) {
+		e.Identifiers = append(e.Identifiers, "urn:isbn:"+isbn)
+	}
+	if b.Author != "" {
+		e.Authors = []person{{Name: b.Author}}
+	}
+	if b.Description != "" {
+		e.Summary = &text{Type: "text", Value: b.Description}
+	}
+	if b.BookImage != "" {
+		e.Links = append(e.Links,
+			link{Href: b.BookImage, Rel: "http://opds-spec.org/image", Type: imageType(b.BookImage)},
+			link{Href: b.BookImage, Rel: "http://opds-spec.org/image/thumbnail", Type: imageType(b.BookImage)},
+		)
+	}
+	if b.AmazonProductURL != "" {
+		e.Links = append(e.Links, link{Href: b.AmazonProductURL, Rel: "http://opds-spec.org/acquisition/buy", Type: "text/html", Title: "Amazon"})
+	}
+
+	return e
+}
+
+// isbns returns the primary and other ISBNs of a book, without duplicates
+func isbns(b books.ListBook) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(isbn string) {
+		if isbn != "" && !seen[isbn] {
+			seen[isbn] = true
+			out = append(out, isbn)
+		}
+	}
+	add(b.PrimaryISBN13)
+	add(b.PrimaryISBN10)
+	for _, i := range b.ISBNs {
+		add(i.ISBN13)
+		add(i.ISBN10)
+	}
+
+	return out
+}
+
+func weeks(n int) string {
+	if n == 1 {
+		return "1 week"
+	}
+
+	return fmt.Sprintf("%d weeks", n)
+}
+
+func (h *Handler) listHref(name string, page int) string {
+	href := h.prefix + "/lists/" + name
+	if page > 1 {
+		href += "?page=" + strconv.Itoa(page)
+	}
+
+	return href
+}
+
+func imageType(link string) string {
+	switch {
+	case strings.HasSuffix(link, ".png"):
+		return "image/png"
+	case strings.HasSuffix(link, ".gif"):
+		return "image/gif"
+	}
+
+	return "image/jpeg"
+}
+
+func atomDate(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
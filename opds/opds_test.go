@@ -0,0 +1,233 @@
+package opds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+type fakeSource struct {
+	mu      sync.Mutex
+	names   *books.Names
+	list    *books.ListByDate
+	offsets []string
+	err     error
+}
+
+func (s *fakeSource) GetBestSellersListNames() (*books.Names, error) {
+	return s.names, s.err
+}
+
+func (s *fakeSource) GetBestSellersListByDate(date, listName string, qp books.QueryParam) (*books.ListByDate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offsets = append(s.offsets, qp["offset"])
+
+	return s.list, s.err
+}
+
+func newSource(t *testing.T) *fakeSource {
+	s := &fakeSource{}
+	for name, v := range map[string]interface{}{"names.json": &s.names, "list_by_date.json": &s.list} {
+		data, err := ioutil.ReadFile("../testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return s
+}
+
+func serve(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+
+	return rec
+}
+
+func TestNavigation(t *testing.T) {
+	src := newSource(t)
+	h := NewHandler(src, WithPrefix("/opds"))
+	rec := serve(t, h, "/opds/")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != NavigationType {
+		t.Fatalf("got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var f feed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &f); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Entries) != len(src.names.Results) {
+		t.Fatalf("got %d entries, want %d", len(f.Entries), len(src.names.Results))
+	}
+	first := src.names.Results[0]
+	e := f.Entries[0]
+	want := link{Href: "/opds/lists/" + first.ListNameEncoded, Rel: "subsection", Type: AcquisitionType}
+	if e.Title != first.DisplayName || len(e.Links) != 1 || e.Links[0] != want {
+		t.Errorf("got entry %+v, want a link to %+v", e, want)
+	}
+}
+
+func TestAcquisition(t *testing.T) {
+	src := newSource(t)
+	src.list.NumResults = 45
+	h := NewHandler(src)
+	rec := serve(t, h, "/lists/combined-print-and-e-book-fiction?page=2")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != AcquisitionType {
+		t.Fatalf("got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if len(src.offsets) != 2 || src.offsets[1] != "20" {
+		t.Errorf("requested offsets %q, want the first page then 20", src.offsets)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<link href="/lists/combined-print-and-e-book-fiction" rel="previous"`,
+		`<link href="/lists/combined-print-and-e-book-fiction?page=3" rel="next"`,
+		`<id>urn:isbn:9780553418026</id>`,
+		`<dc:identifier>urn:isbn:9780553418026</dc:identifier>`,
+		`<dc:identifier>urn:isbn:9780804139021</dc:identifier>`,
+		`rel="http://opds-spec.org/image" type="image/jpeg"`,
+		`rel="http://opds-spec.org/acquisition/buy" type="text/html"`,
+		`<name>Andy Weir</name>`,
+		`xmlns:dc="http://purl.org/dc/terms/"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("feed doesn't contain %s:\n%s", want, body)
+		}
+	}
+
+	// the last page has no next link
+	src.list.NumResults = 21
+	rec = serve(t, NewHandler(src), "/lists/combined-print-and-e-book-fiction?page=2")
+	if strings.Contains(rec.Body.String(), `rel="next"`) {
+		t.Errorf("last page has a next link:\n%s", rec.Body.String())
+	}
+}
+
+func TestCache(t *testing.T) {
+	src := newSource(t)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHandler(src, WithTTL(time.Minute))
+	h.now = func() time.Time { return now }
+
+	serve(t, h, "/lists/combined-print-and-e-book-fiction")
+	serve(t, h, "/lists/combined-print-and-e-book-fiction")
+	if len(src.offsets) != 1 {
+		t.Errorf("made %d calls, want 1", len(src.offsets))
+	}
+	now = now.Add(2 * time.Minute)
+	serve(t, h, "/lists/combined-print-and-e-book-fiction")
+	if len(src.offsets) != 2 {
+		t.Errorf("made %d calls after expiry, want 2", len(src.offsets))
+	}
+}
+
+func TestErrors(t *testing.T) {
+	src := newSource(t)
+	h := NewHandler(src)
+	cases := map[string]int{
+		"/nope":             http.StatusNotFound,
+		"/lists/a/b":        http.StatusNotFound,
+		"/lists/a?page=0":   http.StatusBadRequest,
+		"/lists/a?page=one": http.StatusBadRequest,
+	}
+	for target, want := range cases {
+		if rec := serve(t, h, target); rec.Code != want {
+			t.Errorf("GET %s: got %d, want %d", target, rec.Code, want)
+		}
+	}
+
+	src.err = errors.New("quota exceeded")
+	if rec := serve(t, h, "/"); rec.Code != http.StatusBadGateway {
+		t.Errorf("got %d for an API error, want 502", rec.Code)
+	}
+}
+
+func TestUpstreamErrorHidesKey(t *testing.T) {
+	src := newSource(t)
+	src.err = &url.Error{Op: "Get", URL: "https://api.nytimes.com/svc/books/v3/lists/names.json?api-key=SECRETKEY", Err: errors.New("connection refused")}
+	rec := serve(t, NewHandler(src), "/")
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("got %d, want 502", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "SECRETKEY") {
+		t.Errorf("response leaks the api key: %s", rec.Body.String())
+	}
+}
+
+func TestUnknownPages(t *testing.T) {
+	src := newSource(t)
+	src.list.NumResults = 45
+	h := NewHandler(src)
+	for _, target := range []string{
+		"/lists/not-a-list",
+		"/lists/combined-print-and-e-book-fiction?page=4",
+	} {
+		if rec := serve(t, h, target); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: got %d, want 404", target, rec.Code)
+		}
+	}
+	if rec := serve(t, h, "/lists/combined-print-and-e-book-fiction?page=3"); rec.Code != http.StatusOK {
+		t.Errorf("got %d for the last page, want 200", rec.Code)
+	}
+	// only the first page was asked for, to count the pages, and then the last one
+	if len(src.offsets) != 2 {
+		t.Errorf("made %d list calls, want 2", len(src.offsets))
+	}
+}
+
+func TestCacheSweep(t *testing.T) {
+	src := newSource(t)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHandler(src, WithTTL(time.Minute))
+	h.now = func() time.Time { return now }
+
+	serve(t, h, "/")
+	serve(t, h, "/lists/combined-print-and-e-book-fiction")
+	now = now.Add(2 * time.Minute)
+	serve(t, h, "/")
+	// the names and the navigation feed, the list entries having expired
+	if len(h.cache) != 2 {
+		t.Errorf("got %d cache entries, want 2", len(h.cache))
+	}
+}
+
+func TestErrorPayload(t *testing.T) {
+	src := newSource(t)
+	h := NewHandler(src)
+
+	// what a client without WithStatusErrors decodes from a 429
+	names := src.names
+	src.names = &books.Names{}
+	if rec := serve(t, h, "/lists/combined-print-and-e-book-fiction"); rec.Code != http.StatusBadGateway {
+		t.Errorf("got %d for an error payload of names, want 502", rec.Code)
+	}
+	src.names = names
+
+	list := src.list
+	src.list = &books.ListByDate{}
+	if rec := serve(t, h, "/lists/combined-print-and-e-book-fiction"); rec.Code != http.StatusBadGateway {
+		t.Errorf("got %d for an error payload of a list, want 502", rec.Code)
+	}
+	src.list = list
+
+	// neither was cached
+	if rec := serve(t, h, "/lists/combined-print-and-e-book-fiction"); rec.Code != http.StatusOK {
+		t.Errorf("got %d once the API answers, want 200", rec.Code)
+	}
+}
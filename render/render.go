@@ -0,0 +1,295 @@
+// Package render turns lists, overviews and reviews into Markdown tables
+// and HTML fragments, for wikis and intranet pages.
+//
+// Both formats are rendered from templates that can be overridden. HTML
+// templates use html/template, so all API text is escaped in context; the
+// md and mdurl functions escape text and links in Markdown templates.
+package render
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// Movement is how a book moved on its list since last week
+type Movement string
+
+// Movements of a Book
+const (
+	Up   Movement = "up"
+	Down Movement = "down"
+	Same Movement = "same"
+	// Entered is a book that wasn't on the list last week
+	Entered Movement = "entered"
+)
+
+// Arrow returns an arrow for the movement, empty for Entered
+// or unknown movements
+func (m Movement) Arrow() string {
+	switch m {
+	case Up:
+		return "▲"
+	case Down:
+		return "▼"
+	case Same:
+		return "="
+	}
+
+	return ""
+}
+
+// Book is a book as passed to the templates
+type Book struct {
+	Rank         int
+	RankLastWeek int
+	WeeksOnList  int
+	// Movement is empty when unknown, as in overviews
+	Movement Movement
+	// New is set for books in their first week on the list
+	New         bool
+	Title       string
+	Author      string
+	Description string
+	Publisher   string
+	ISBN13      string
+	Image       string
+	AmazonURL   string
+	ReviewURL   string
+}
+
+// List is a list as passed to the templates
+type List struct {
+	Name          string
+	DisplayName   string
+	PublishedDate string
+	Image         string
+	Books         []Book
+}
+
+// Overview is an overview as passed to the templates
+type Overview struct {
+	PublishedDate string
+	Lists         []List
+}
+
+// Reviews are reviews as passed to the templates
+type Reviews struct {
+	Reviews []books.Review
+}
+
+// FromList converts a list to its template data
+func FromList(l *books.ListByDate) List {
+	list := List{
+		Name:          l.Results.ListName,
+		DisplayName:   l.Results.DisplayName,
+		PublishedDate: l.Results.PublishedDate,
+	}
+	for _, b := range l.Results.Books {
+		review := httpURL(b.BookReviewLink)
+		if review == "" {
+			review = httpURL(b.SundayReviewLink)
+		}
+		list.Books = append(list.Books, Book{
+			Rank:         b.Rank,
+			RankLastWeek: b.RankLastWeek,
+			WeeksOnList:  b.WeeksOnList,
+			Movement:     movement(b.Rank, b.RankLastWeek),
+			New:          b.WeeksOnList <= 1,
+			Title:        b.Title,
+			Author:       b.Author,
+			Description:  b.Description,
+			Publisher:    b.Publisher,
+			ISBN13:       b.PrimaryISBN13,
+			Image:        httpURL(b.BookImage),
+			AmazonURL:    httpURL(b.AmazonProductURL),
+			ReviewURL:    review,
+		})
+	}
+
+	return list
+}
+
+// FromOverview converts an overview to its template data. Overviews don't
+// tell how books moved, nor how long they have been on their list.
+func FromOverview(o *books.Overview) Overview {
+	overview := Overview{PublishedDate: o.Results.PublishedDate}
+	for _, l := range o.Results.Lists {
+		list := List{
+			Name:          l.ListName,
+			DisplayName:   l.DisplayName,
+			PublishedDate: o.Results.PublishedDate,
+			Image:         httpURL(l.ListImage),
+		}
+		for _, b := range l.Books {
+			list.Books = append(list.Books, Book{
+				Rank:        b.Rank,
+				Title:       b.Title,
+				Author:      b.Author,
+				Description: b.Description,
+				Publisher:   b.Publisher,
+				ISBN13:      b.PrimaryISBN13,
+			})
+		}
+		overview.Lists = append(overview.Lists, list)
+	}
+
+	return overview
+}
+
+func movement(rank, lastWeek int) Movement {
+	switch {
+	case lastWeek == 0:
+		return Entered
+	case rank < lastWeek:
+		return Up
+	case rank > lastWeek:
+		return Down
+	}
+
+	return Same
+}
+
+// Renderer renders Markdown and HTML from templates. The templates
+// defining the documents are named "list", "overview" and "reviews",
+// and the default ones are built from smaller templates such as
+// "book", which can be overridden on their own.
+type Renderer struct {
+	htmlTexts map[string]string
+	mdTexts   map[string]string
+	html      *htmltemplate.Template
+	md        *texttemplate.Template
+}
+
+// New constructs a Renderer with the default templates
+func New() *Renderer {
+	r := &Renderer{
+		htmlTexts: copyTexts(defaultHTML),
+		mdTexts:   copyTexts(defaultMarkdown),
+	}
+	if err := r.build(); err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+func copyTexts(texts map[string]string) map[string]string {
+	c := make(map[string]string, len(texts))
+	for name, text := range texts {
+		c[name] = text
+	}
+
+	return c
+}
+
+// OverrideHTML replaces or adds the HTML template name
+func (r *Renderer) OverrideHTML(name, text string) error {
+	old := r.htmlTexts[name]
+	r.htmlTexts[name] = text
+	if err := r.build(); err != nil {
+		r.htmlTexts[name] = old
+		return err
+	}
+
+	return nil
+}
+
+// OverrideMarkdown replaces or adds the Markdown template name
+func (r *Renderer) OverrideMarkdown(name, text string) error {
+	old := r.mdTexts[name]
+	r.mdTexts[name] = text
+	if err := r.build(); err != nil {
+		r.mdTexts[name] = old
+		return err
+	}
+
+	return nil
+}
+
+// build parses the templates into new sets, as html/template
+// sets can't be changed once executed
+func (r *Renderer) build() error {
+	html := htmltemplate.New("")
+	for name, text := range r.htmlTexts {
+		if _, err := html.New(name).Parse(text); err != nil {
+			return err
+		}
+	}
+	md := texttemplate.New("").Funcs(texttemplate.FuncMap{
+		"md":    Markdown,
+		"mdurl": MarkdownURL,
+	})
+	for name, text := range r.mdTexts {
+		if _, err := md.New(name).Parse(text); err != nil {
+			return err
+		}
+	}
+	r.html, r.md = html, md
+
+	return nil
+}
+
+// ListHTML renders a list as an HTML fragment
+func (r *Renderer) ListHTML(w io.Writer, l *books.ListByDate) error {
+	return r.html.ExecuteTemplate(w, "list", FromList(l))
+}
+
+// ListMarkdown renders a list as a Markdown table
+func (r *Renderer) ListMarkdown(w io.Writer, l *books.ListByDate) error {
+	return r.md.ExecuteTemplate(w, "list", FromList(l))
+}
+
+// OverviewHTML renders an overview as an HTML fragment
+func (r *Renderer) OverviewHTML(w io.Writer, o *books.Overview) error {
+	return r.html.ExecuteTemplate(w, "overview", FromOverview(o))
+}
+
+// OverviewMarkdown renders an overview as Markdown tables, one per list
+func (r *Renderer) OverviewMarkdown(w io.Writer, o *books.Overview) error {
+	return r.md.ExecuteTemplate(w, "overview", FromOverview(o))
+}
+
+// ReviewsHTML renders reviews as an HTML fragment
+func (r *Renderer) ReviewsHTML(w io.Writer, reviews *books.Reviews) error {
+	return r.html.ExecuteTemplate(w, "reviews", Reviews{Reviews: reviews.Results})
+}
+
+// ReviewsMarkdown renders reviews as a Markdown list
+func (r *Renderer) ReviewsMarkdown(w io.Writer, reviews *books.Reviews) error {
+	return r.md.ExecuteTemplate(w, "reviews", Reviews{Reviews: reviews.Results})
+}
+
+// markdownEscaper escapes the characters with a meaning in Markdown,
+// and folds line breaks, which would end a table row
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "{", `\{`, "}", `\}`,
+	"[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "#", `\#`, "+", `\+`,
+	"!", `\!`, "|", `\|`, "<", "&lt;", ">", "&gt;", "&", "&amp;",
+	"\r\n", " ", "\n", " ", "\r", " ",
+)
+
+// Markdown escapes text for Markdown
+func Markdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// MarkdownURL makes link safe for a Markdown link or image: links that
+// aren't http or https are dropped, and characters ending a link escaped
+func MarkdownURL(link string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E").Replace(httpURL(link))
+}
+
+// httpURL returns link if it is an http or https URL, and "" otherwise
+func httpURL(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+
+	return u.String()
+}
@@ -0,0 +1,185 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func loadJSON(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := ioutil.ReadFile("../testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testList is the sample list with a second, hostile book
+func testList(t *testing.T) *books.ListByDate {
+	var l books.ListByDate
+	loadJSON(t, "list_by_date.json", &l)
+	b := l.Results.Books[0]
+	b.Rank, b.RankLastWeek, b.WeeksOnList = 2, 0, 1
+	b.Title = `<script>alert("x")</script> | *BOLD*`
+	b.BookReviewLink = "javascript:alert(1)"
+	b.BookImage = ""
+	l.Results.Books = append(l.Results.Books, b)
+
+	return &l
+}
+
+func TestMovement(t *testing.T) {
+	cases := []struct {
+		rank, lastWeek int
+		want           Movement
+	}{
+		{1, 3, Up},
+		{3, 1, Down},
+		{2, 2, Same},
+		{5, 0, Entered},
+	}
+	for _, tc := range cases {
+		if got := movement(tc.rank, tc.lastWeek); got != tc.want {
+			t.Errorf("movement(%d, %d) == %s, want %s", tc.rank, tc.lastWeek, got, tc.want)
+		}
+	}
+}
+
+func TestListHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New().ListHTML(&buf, testList(t)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`<h2>Paperback Trade Fiction</h2>`,
+		`<img class="nytbooks-cover" src="http://du.ec2.nytimes.com.s3.amazonaws.com/prd/books/9780804139038.jpg"`,
+		`<cite class="nytbooks-title">&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; | *BOLD*</cite>`,
+		`<span class="nytbooks-badge">New this week</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML doesn't contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<script>") || strings.Contains(out, "javascript:") {
+		t.Errorf("HTML isn't escaped:\n%s", out)
+	}
+	if strings.Count(out, "New this week") != 1 {
+		t.Errorf("want a single new book:\n%s", out)
+	}
+}
+
+func TestListMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New().ListMarkdown(&buf, testList(t)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	rows := lines[len(lines)-2:]
+
+	if !strings.HasPrefix(rows[0], "| 1 | ![Cover](http://du.ec2.nytimes.com") ||
+		!strings.Contains(rows[0], "**THE MARTIAN** by Andy Weir · [Buy](http://www.amazon.com/") {
+		t.Errorf("got row %q", rows[0])
+	}
+	want := `| 2 |  | **&lt;script&gt;alert\("x"\)&lt;/script&gt; \| \*BOLD\*** by Andy Weir **New this week** · [Buy]`
+	if !strings.HasPrefix(rows[1], want) {
+		t.Errorf("got row %q, want it to start with %q", rows[1], want)
+	}
+	// every row has the four columns of the header
+	for _, row := range rows {
+		if n := strings.Count(row, "|") - strings.Count(row, `\|`); n != 5 {
+			t.Errorf("row %q has %d separators, want 5", row, n)
+		}
+	}
+}
+
+func TestOverview(t *testing.T) {
+	var o books.Overview
+	loadJSON(t, "overview.json", &o)
+	r := New()
+
+	var md, html bytes.Buffer
+	if err := r.OverviewMarkdown(&md, &o); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.OverviewHTML(&html, &o); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "## Combined Print &amp; E-Book Fiction") {
+		t.Errorf("got Markdown:\n%s", md.String())
+	}
+	if !strings.Contains(md.String(), "| 1 |  | **THE GANGSTER** by Clive Cussler and Justin Scott |  |") {
+		t.Errorf("got Markdown:\n%s", md.String())
+	}
+	if !strings.Contains(html.String(), "<h2>Combined Print &amp; E-Book Fiction</h2>") {
+		t.Errorf("got HTML:\n%s", html.String())
+	}
+}
+
+func TestReviews(t *testing.T) {
+	var reviews books.Reviews
+	loadJSON(t, "reviews.json", &reviews)
+	r := New()
+
+	var md, html bytes.Buffer
+	if err := r.ReviewsMarkdown(&md, &reviews); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ReviewsHTML(&html, &reviews); err != nil {
+		t.Fatal(err)
+	}
+	wantMD := "- [_1Q84_](http://www.nytimes.com/2011/11/10/books/1q84-by-haruki-murakami-review.html) by Haruki Murakami, reviewed by JANET MASLIN on 2011-11-10: "
+	if !strings.HasPrefix(md.String(), wantMD) {
+		t.Errorf("got Markdown %q, want it to start with %q", md.String(), wantMD)
+	}
+	if !strings.Contains(html.String(), `<a href="http://www.nytimes.com/2011/11/10/books/1q84-by-haruki-murakami-review.html"><cite>1Q84</cite></a> by Haruki Murakami`) {
+		t.Errorf("got HTML:\n%s", html.String())
+	}
+}
+
+func TestOverride(t *testing.T) {
+	r := New()
+	if err := r.OverrideHTML("book", `<li>{{.Title}}</li>`); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := r.ListHTML(&buf, testList(t)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<li>THE MARTIAN</li>") {
+		t.Errorf("book template not overridden:\n%s", buf.String())
+	}
+
+	// overriding after rendering works too, and bad templates are rejected
+	if err := r.OverrideMarkdown("book", `{{.Title}}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.OverrideHTML("book", `{{.Title`); err == nil {
+		t.Error("expected an error for a broken template")
+	}
+	buf.Reset()
+	if err := r.ListHTML(&buf, testList(t)); err != nil || !strings.Contains(buf.String(), "<li>THE MARTIAN</li>") {
+		t.Errorf("a broken override replaced the template: %v\n%s", err, buf.String())
+	}
+}
+
+func TestMarkdownURL(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/a (b)": "https://example.com/a%20%28b%29",
+		"javascript:alert(1)":       "",
+		"":                          "",
+	}
+	for link, want := range cases {
+		if got := MarkdownURL(link); got != want {
+			t.Errorf("MarkdownURL(%q) == %q, want %q", link, got, want)
+		}
+	}
+}
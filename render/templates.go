@@ -0,0 +1,93 @@
+package render
+
+// defaultHTML are the default HTML templates
+var defaultHTML = map[string]string{
+	"list": `<section class="nytbooks-list">
+<h2>{{.DisplayName}}</h2>
+{{- if .PublishedDate}}
+<p class="nytbooks-published">Published {{.PublishedDate}}</p>
+{{- end}}
+<ol class="nytbooks-books">
+{{- range .Books}}
+{{template "book" .}}
+{{- end}}
+</ol>
+</section>
+`,
+
+	"book": `<li class="nytbooks-book" value="{{.Rank}}">
+{{- if .Image}}
+<img class="nytbooks-cover" src="{{.Image}}" alt="Cover of {{.Title}}" loading="lazy">
+{{- end}}
+<span class="nytbooks-rank">{{.Rank}}</span>
+{{- with .Movement.Arrow}}
+<span class="nytbooks-movement nytbooks-{{$.Movement}}" title="Rank last week: {{$.RankLastWeek}}">{{.}}</span>
+{{- end}}
+{{- if .New}}
+<span class="nytbooks-badge">New this week</span>
+{{- end}}
+<cite class="nytbooks-title">{{.Title}}</cite>
+{{- if .Author}}
+<span class="nytbooks-author">by {{.Author}}</span>
+{{- end}}
+{{- if .Description}}
+<p class="nytbooks-description">{{.Description}}</p>
+{{- end}}
+{{- if or .ReviewURL .AmazonURL}}
+<p class="nytbooks-links">
+{{- if .ReviewURL}}<a href="{{.ReviewURL}}">Review</a>{{end}}
+{{- if and .ReviewURL .AmazonURL}} · {{end}}
+{{- if .AmazonURL}}<a href="{{.AmazonURL}}" rel="nofollow">Buy</a>{{end -}}
+</p>
+{{- end}}
+</li>`,
+
+	"overview": `<div class="nytbooks-overview">
+{{- range .Lists}}
+{{template "list" .}}
+{{- end}}
+</div>
+`,
+
+	"reviews": `<ul class="nytbooks-reviews">
+{{- range .Reviews}}
+<li class="nytbooks-review">
+<a href="{{.URL}}"><cite>{{.BookTitle}}</cite></a>
+{{- if .BookAuthor}} by {{.BookAuthor}}{{end}}
+<p class="nytbooks-byline">{{.ByLine}}{{if .PublicationDt}}, {{.PublicationDt}}{{end}}</p>
+{{- if .Summary}}
+<p class="nytbooks-summary">{{.Summary}}</p>
+{{- end}}
+</li>
+{{- end}}
+</ul>
+`,
+}
+
+// defaultMarkdown are the default Markdown templates
+var defaultMarkdown = map[string]string{
+	"list": `## {{md .DisplayName}}
+{{if .PublishedDate}}
+_Published {{md .PublishedDate}}_
+{{end}}
+| Rank | | Book | Weeks on list |
+| ---: | --- | --- | ---: |
+{{range .Books}}{{template "book" .}}
+{{end}}`,
+
+	"book": `| {{.Rank}}{{with .Movement.Arrow}} {{.}}{{end}} | {{with mdurl .Image}}![Cover]({{.}}){{end}} | **{{md .Title}}**
+{{- if .Author}} by {{md .Author}}{{end}}
+{{- if .New}} **New this week**{{end}}
+{{- with mdurl .ReviewURL}} · [Review]({{.}}){{end}}
+{{- with mdurl .AmazonURL}} · [Buy]({{.}}){{end}} | {{if .WeeksOnList}}{{.WeeksOnList}}{{end}} |`,
+
+	"overview": `{{range $i, $l := .Lists}}{{if $i}}
+{{end}}{{template "list" $l}}{{end}}`,
+
+	"reviews": `{{range $r := .Reviews}}- {{with mdurl $r.URL}}[_{{md $r.BookTitle}}_]({{.}}){{else}}_{{md $r.BookTitle}}_{{end}}
+{{- if $r.BookAuthor}} by {{md $r.BookAuthor}}{{end}}
+{{- if $r.ByLine}}, reviewed by {{md $r.ByLine}}{{end}}
+{{- if $r.PublicationDt}} on {{md $r.PublicationDt}}{{end}}
+{{- if $r.Summary}}: {{md $r.Summary}}{{end}}
+{{end}}`,
+}
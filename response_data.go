@@ -41,6 +41,9 @@ type List struct {
 			ArticleChapterLink string `json:"article_chapter_link"`
 		} `json:"reviews"`
 	} `json:"results"`
+
+	// Warnings are only set in strict decoding mode
+	Warnings []DecodeWarning `json:"-"`
 }
 
 // ListByDate defines the structure of the response gotten on 
@@ -51,82 +54,106 @@ type ListByDate struct {
 	NumResults   int    `json:"num_results"`
 	LastModified string `json:"last_modified"`
 	Results      struct {
-		ListName         string `json:"list_name"`
-		BestsellersDate  string `json:"bestsellers_date"`
-		PublishedDate    string `json:"published_date"`
-		DisplayName      string `json:"display_name"`
-		NormalListEndsAt int    `json:"normal_list_ends_at"`
-		Updated          string `json:"updated"`
-		Books            []struct {
-			Rank               int    `json:"rank"`
-			RankLastWeek       int    `json:"rank_last_week"`
-			WeeksOnList        int    `json:"weeks_on_list"`
-			Asterisk           int    `json:"asterisk"`
-			Dagger             int    `json:"dagger"`
-			PrimaryISBN13      string `json:"primary_isbn13"`
-			PrimaryISBN10      string `json:"primary_isbn10"`
-			Publisher          string `json:"publisher"`
-			Description        string `json:"description"`
-			Price              int    `json:"price"`
-			Title              string `json:"title"`
-			Author             string `json:"author"`
-			Contributor        string `json:"contributor"`
-			ContributorNote    string `json:"contributor_note"`
-			BookImage          string `json:"book_image"`
-			AmazonProductURL   string `json:"amazon_product_url"`
-			AgeGroup           string `json:"age_group"`
-			BookReviewLink     string `json:"book_review_link"`
-			FirstChapterLink   string `json:"first_chapter_link"`
-			SundayReviewLink   string `json:"sunday_review_link"`
-			ArticleChapterLink string `json:"article_chapter_link"`
-			ISBNs              []struct {
-				ISBN10 string `json:"isbn10"`
-				ISBN13 string `json:"isbn13"`
-			} `json:"isbns"`
-		} `json:"books"`
-		Corrections []struct{} `json:"corrections"`
+		ListName         string       `json:"list_name"`
+		BestsellersDate  string       `json:"bestsellers_date"`
+		PublishedDate    string       `json:"published_date"`
+		DisplayName      string       `json:"display_name"`
+		NormalListEndsAt int          `json:"normal_list_ends_at"`
+		Updated          string       `json:"updated"`
+		Books            []ListBook   `json:"books"`
+		Corrections      []Correction `json:"corrections"`
 	} `json:"results"`
+
+	// Warnings are only set in strict decoding mode
+	Warnings []DecodeWarning `json:"-"`
+}
+
+// ListBook is a book of a best sellers list
+type ListBook struct {
+	Rank               int    `json:"rank"`
+	RankLastWeek       int    `json:"rank_last_week"`
+	WeeksOnList        int    `json:"weeks_on_list"`
+	Asterisk           int    `json:"asterisk"`
+	Dagger             int    `json:"dagger"`
+	PrimaryISBN13      string `json:"primary_isbn13"`
+	PrimaryISBN10      string `json:"primary_isbn10"`
+	Publisher          string `json:"publisher"`
+	Description        string `json:"description"`
+	Price              int    `json:"price"`
+	Title              string `json:"title"`
+	Author             string `json:"author"`
+	Contributor        string `json:"contributor"`
+	ContributorNote    string `json:"contributor_note"`
+	BookImage          string `json:"book_image"`
+	AmazonProductURL   string `json:"amazon_product_url"`
+	AgeGroup           string `json:"age_group"`
+	BookReviewLink     string `json:"book_review_link"`
+	FirstChapterLink   string `json:"first_chapter_link"`
+	SundayReviewLink   string `json:"sunday_review_link"`
+	ArticleChapterLink string `json:"article_chapter_link"`
+	ISBNs              []struct {
+		ISBN10 string `json:"isbn10"`
+		ISBN13 string `json:"isbn13"`
+	} `json:"isbns"`
+}
+
+// Correction is a correction NYT published to a list edition,
+// giving the right title and ISBN for a rank
+type Correction struct {
+	Title          string `json:"title"`
+	PrimaryISBN13  string `json:"primary_isbn13"`
+	PrimaryISBN10  string `json:"primary_isbn10"`
+	Rank           int    `json:"rank"`
+	CorrectionText string `json:"correction_text"`
+	CorrectionDate string `json:"correction_date"`
 }
 
 // ListHistory defines the structure of the response gotten on 
 // requesting best sellers list history
 type ListHistory struct {
-	Status     string `json:"status"`
-	Copyright  string `json:"copyright"`
-	NumResults int    `json:"num_results"`
-	Results    []struct {
-		Title           string `json:"title"`
-		Description     string `json:"description"`
-		Contributor     string `json:"contributor"`
-		Author          string `json:"author"`
-		ContributorNote string `json:"contributor_note"`
-		Price           int    `json:"price"`
-		AgeGroup        string `json:"age_group"`
-		Publisher       string `json:"publisher"`
-		ISBNs           []struct {
-			ISBN10 string `json:"isbn10"`
-			ISBN13 string `json:"isbn13"`
-		} `json:"isbns"`
-		RanksHistory []struct {
-			PrimaryISBN10   string `json:"primary_isbn10"`
-			PrimaryISBN13   string `json:"primary_isbn13"`
-			Rank            int    `json:"rank"`
-			ListName        string `json:"list_name"`
-			DisplayName     string `json:"display_name"`
-			PublishedDate   string `json:"published_date"`
-			BestsellersDate string `json:"bestsellers_date"`
-			WeeksOnList     int    `json:"weeks_on_list"`
-			RanksLastWeek   int    `json:"ranks_last_week"`
-			Asterisk        int    `json:"asterisk"`
-			Dagger          int    `json:"dagger"`
-		} `json:"rank_history"`
-		Reviews []struct {
-			BookReviewLink     string `json:"book_review_link"`
-			FirstChapterLink   string `json:"first_chapter_link"`
-			SundayReviewLink   string `json:"sunday_review_link"`
-			ArticleChapterLink string `json:"article_chapter_link"`
-		} `json:"reviews"`
-	} `json:"results"`
+	Status     string        `json:"status"`
+	Copyright  string        `json:"copyright"`
+	NumResults int           `json:"num_results"`
+	Results    []HistoryBook `json:"results"`
+
+	// Warnings are only set in strict decoding mode
+	Warnings []DecodeWarning `json:"-"`
+}
+
+// HistoryBook is a book of a best sellers list history,
+// with its ranks on every list it appeared on
+type HistoryBook struct {
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	Contributor     string `json:"contributor"`
+	Author          string `json:"author"`
+	ContributorNote string `json:"contributor_note"`
+	Price           int    `json:"price"`
+	AgeGroup        string `json:"age_group"`
+	Publisher       string `json:"publisher"`
+	ISBNs           []struct {
+		ISBN10 string `json:"isbn10"`
+		ISBN13 string `json:"isbn13"`
+	} `json:"isbns"`
+	RanksHistory []struct {
+		PrimaryISBN10   string `json:"primary_isbn10"`
+		PrimaryISBN13   string `json:"primary_isbn13"`
+		Rank            int    `json:"rank"`
+		ListName        string `json:"list_name"`
+		DisplayName     string `json:"display_name"`
+		PublishedDate   string `json:"published_date"`
+		BestsellersDate string `json:"bestsellers_date"`
+		WeeksOnList     int    `json:"weeks_on_list"`
+		RanksLastWeek   int    `json:"ranks_last_week"`
+		Asterisk        int    `json:"asterisk"`
+		Dagger          int    `json:"dagger"`
+	} `json:"ranks_history"`
+	Reviews []struct {
+		BookReviewLink     string `json:"book_review_link"`
+		FirstChapterLink   string `json:"first_chapter_link"`
+		SundayReviewLink   string `json:"sunday_review_link"`
+		ArticleChapterLink string `json:"article_chapter_link"`
+	} `json:"reviews"`
 }
 
 // Names defines the structure of the response gotten on 
@@ -143,6 +170,9 @@ type Names struct {
 		NewestPublishedDate string `json:"newest_published_date"`
 		Updated             string `json:"updated"`
 	} `json:"results"`
+
+	// Warnings are only set in strict decoding mode
+	Warnings []DecodeWarning `json:"-"`
 }
 
 // Overview defines the structure of the response gotten on 
@@ -152,46 +182,61 @@ type Overview struct {
 	Copyright  string `json:"copyright"`
 	NumResults int    `json:"num_results"`
 	Results    struct {
-		BestsellersDate string `json:"bestsellers_date"`
-		PublishedDate   string `json:"published_date"`
-		Lists           []struct {
-			ListID      int    `json:"list_id"`
-			ListName    string `json:"list_name"`
-			DisplayName string `json:"display_name"`
-			Updated     string `json:"updated"`
-			ListImage   string `json:"list_image"`
-			Books       []struct {
-				AgeGroup        string `json:"age_group"`
-				Author          string `json:"author"`
-				Contributor     string `json:"contributor"`
-				ContributorNote string `json:"contributor_note"`
-				CreatedDate     string `json:"created_date"`
-				Description     string `json:"description"`
-				Price           int    `json:"price"`
-				PrimaryISBN13   string `json:"primary_isbn13"`
-				PrimaryISBN10   string `json:"primary_isbn10"`
-				Publisher       string `json:"publisher"`
-				Rank            int    `json:"rank"`
-				Title           string `json:"title"`
-				UpdatedDate     string `json:"updated_date"`
-			} `json:"books"`
-		}
+		BestsellersDate string         `json:"bestsellers_date"`
+		PublishedDate   string         `json:"published_date"`
+		Lists           []OverviewList `json:"lists"`
 	} `json:"results"`
+
+	// Warnings are only set in strict decoding mode
+	Warnings []DecodeWarning `json:"-"`
+}
+
+// OverviewList is a best sellers list of an overview
+type OverviewList struct {
+	ListID      int            `json:"list_id"`
+	ListName    string         `json:"list_name"`
+	DisplayName string         `json:"display_name"`
+	Updated     string         `json:"updated"`
+	ListImage   string         `json:"list_image"`
+	Books       []OverviewBook `json:"books"`
+}
+
+// OverviewBook is a book of an overview list
+type OverviewBook struct {
+	AgeGroup        string `json:"age_group"`
+	Author          string `json:"author"`
+	Contributor     string `json:"contributor"`
+	ContributorNote string `json:"contributor_note"`
+	CreatedDate     string `json:"created_date"`
+	Description     string `json:"description"`
+	Price           int    `json:"price"`
+	PrimaryISBN13   string `json:"primary_isbn13"`
+	PrimaryISBN10   string `json:"primary_isbn10"`
+	Publisher       string `json:"publisher"`
+	Rank            int    `json:"rank"`
+	Title           string `json:"title"`
+	UpdatedDate     string `json:"updated_date"`
 }
 
 // Reviews defines the structure of the response gotten on 
 // requesting book reviews
 type Reviews struct {
-	Status     string `json:"status"`
-	Copyright  string `json:"copyright"`
-	NumResults int    `json:"num_results"`
-	Results    []struct {
-		URL           string   `json:"url"`
-		PublicationDt string   `json:"publication_dt"`
-		ByLine        string   `json:"by_line"`
-		BookTitle     string   `json:"book_title"`
-		BookAuthor    string   `json:"book_author"`
-		Summary       string   `json:"summary"`
-		ISBN13        []string `json:"isbn13"`
-	} `json:"results"`
+	Status     string   `json:"status"`
+	Copyright  string   `json:"copyright"`
+	NumResults int      `json:"num_results"`
+	Results    []Review `json:"results"`
+
+	// Warnings are only set in strict decoding mode
+	Warnings []DecodeWarning `json:"-"`
+}
+
+// Review is a single book review
+type Review struct {
+	URL           string   `json:"url"`
+	PublicationDt string   `json:"publication_dt"`
+	ByLine        string   `json:"byline"`
+	BookTitle     string   `json:"book_title"`
+	BookAuthor    string   `json:"book_author"`
+	Summary       string   `json:"summary"`
+	ISBN13        []string `json:"isbn13"`
 }
@@ -0,0 +1,42 @@
+package books
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func decodeTestdata(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The API names the ranks of a history book ranks_history, not rank_history
+func TestDecodeRanksHistory(t *testing.T) {
+	var hist ListHistory
+	decodeTestdata(t, "history.json", &hist)
+
+	ranks := hist.Results[0].RanksHistory
+	if len(ranks) != 1 {
+		t.Fatalf("got %d ranks, want 1", len(ranks))
+	}
+	if r := ranks[0]; r.ListName != "Business Books" || r.Rank != 8 || r.PrimaryISBN13 != "9781591847939" {
+		t.Errorf("got rank %+v", r)
+	}
+}
+
+// The API names the reviewer byline, not by_line
+func TestDecodeReviewByline(t *testing.T) {
+	var reviews Reviews
+	decodeTestdata(t, "reviews.json", &reviews)
+
+	if got := reviews.Results[0].ByLine; got != "JANET MASLIN" {
+		t.Errorf("got byline %q, want JANET MASLIN", got)
+	}
+}
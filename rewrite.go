@@ -0,0 +1,37 @@
+package books
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// HostRewriter is a Doer that sends every request to another host,
+// for instance a nytbooks-proxy, keeping the path and query unchanged
+type HostRewriter struct {
+	target *url.URL
+	doer   Doer
+}
+
+// NewHostRewriter constructs a HostRewriter sending requests to target
+// through doer. If doer is nil, http.DefaultClient is used.
+func NewHostRewriter(target string, doer Doer) (*HostRewriter, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+
+	return &HostRewriter{target: u, doer: doer}, nil
+}
+
+// Do rewrites the request's scheme and host then sends it
+func (h *HostRewriter) Do(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = h.target.Scheme
+	r.URL.Host = h.target.Host
+	r.Host = h.target.Host
+
+	return h.doer.Do(r)
+}
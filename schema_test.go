@@ -0,0 +1,16 @@
+package books_test
+
+import (
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/schematest"
+)
+
+func TestSchemaDrift(t *testing.T) {
+	schematest.Check(t, "testdata/list_by_date*.json", books.ListByDate{})
+	schematest.Check(t, "testdata/history*.json", books.ListHistory{})
+	schematest.Check(t, "testdata/names*.json", books.Names{})
+	schematest.Check(t, "testdata/overview*.json", books.Overview{})
+	schematest.Check(t, "testdata/reviews*.json", books.Reviews{})
+}
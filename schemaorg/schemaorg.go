@@ -0,0 +1,362 @@
+// Package schemaorg converts books, lists and reviews to schema.org
+// JSON-LD and Open Graph tags, for the structured data of public pages.
+//
+// The values are checked against the properties search engines require
+// before being written, so that pages embedding them stay eligible for
+// rich results.
+package schemaorg
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/analytics"
+)
+
+// Context is the JSON-LD context of schema.org
+const Context = "https://schema.org"
+
+// Book is a schema.org Book
+type Book struct {
+	Context     string        `json:"@context,omitempty"`
+	Type        string        `json:"@type"`
+	Name        string        `json:"name"`
+	Author      []Person      `json:"author,omitempty"`
+	Publisher   *Organization `json:"publisher,omitempty"`
+	ISBN        string        `json:"isbn,omitempty"`
+	Image       string        `json:"image,omitempty"`
+	Description string        `json:"description,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	Review      []Review      `json:"review,omitempty"`
+}
+
+// Person is a schema.org Person
+type Person struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// Organization is a schema.org Organization
+type Organization struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// ItemList is a schema.org ItemList, in rank order
+type ItemList struct {
+	Context         string     `json:"@context,omitempty"`
+	Type            string     `json:"@type"`
+	Name            string     `json:"name,omitempty"`
+	ItemListOrder   string     `json:"itemListOrder,omitempty"`
+	NumberOfItems   int        `json:"numberOfItems"`
+	ItemListElement []ListItem `json:"itemListElement"`
+}
+
+// ListItem is a schema.org ListItem, its position being the book's rank
+type ListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Item     *Book  `json:"item"`
+}
+
+// Review is a schema.org Review
+type Review struct {
+	Context       string  `json:"@context,omitempty"`
+	Type          string  `json:"@type"`
+	URL           string  `json:"url,omitempty"`
+	Author        *Person `json:"author"`
+	DatePublished string  `json:"datePublished,omitempty"`
+	Description   string  `json:"description,omitempty"`
+	ItemReviewed  *Book   `json:"itemReviewed,omitempty"`
+}
+
+func newBook(title, author, publisher, isbn, image, description string) Book {
+	b := Book{
+		Type:        "Book",
+		Name:        title,
+		ISBN:        isbn,
+		Image:       image,
+		Description: description,
+	}
+	authors, with := analytics.SplitAuthors(author)
+	for _, name := range append(authors, with...) {
+		b.Author = append(b.Author, Person{Type: "Person", Name: name})
+	}
+	if publisher != "" {
+		b.Publisher = &Organization{Type: "Organization", Name: publisher}
+	}
+
+	return b
+}
+
+// FromListBook converts a book of a list
+func FromListBook(b books.ListBook) Book {
+	book := newBook(b.Title, b.Author, b.Publisher, b.PrimaryISBN13, b.BookImage, b.Description)
+	book.URL = b.AmazonProductURL
+
+	return book
+}
+
+// FromOverviewBook converts a book of an overview
+func FromOverviewBook(b books.OverviewBook) Book {
+	return newBook(b.Title, b.Author, b.Publisher, b.PrimaryISBN13, "", b.Description)
+}
+
+// FromList converts a list to an ItemList of its books
+func FromList(l *books.ListByDate) ItemList {
+	list := newItemList(l.Results.DisplayName)
+	for _, b := range l.Results.Books {
+		book := FromListBook(b)
+		list.ItemListElement = append(list.ItemListElement, ListItem{Type: "ListItem", Position: b.Rank, Item: &book})
+	}
+	list.NumberOfItems = len(list.ItemListElement)
+
+	return list
+}
+
+// FromOverviewList converts a list of an overview to an ItemList
+func FromOverviewList(l books.OverviewList) ItemList {
+	list := newItemList(l.DisplayName)
+	for _, b := range l.Books {
+		book := FromOverviewBook(b)
+		list.ItemListElement = append(list.ItemListElement, ListItem{Type: "ListItem", Position: b.Rank, Item: &book})
+	}
+	list.NumberOfItems = len(list.ItemListElement)
+
+	return list
+}
+
+func newItemList(name string) ItemList {
+	return ItemList{
+		Context:         Context,
+		Type:            "ItemList",
+		Name:            name,
+		ItemListOrder:   "https://schema.org/ItemListOrderAscending",
+		ItemListElement: []ListItem{},
+	}
+}
+
+// FromReview converts a review of a book
+func FromReview(r books.Review) Review {
+	isbn := ""
+	if len(r.ISBN13) > 0 {
+		isbn = r.ISBN13[0]
+	}
+	book := newBook(r.BookTitle, r.BookAuthor, "", isbn, "", "")
+	review := Review{
+		Context:       Context,
+		Type:          "Review",
+		URL:           r.URL,
+		DatePublished: r.PublicationDt,
+		Description:   r.Summary,
+		ItemReviewed:  &book,
+	}
+	if r.ByLine != "" {
+		review.Author = &Person{Type: "Person", Name: analytics.NormalizeAuthor(r.ByLine)}
+	}
+
+	return review
+}
+
+// WithReviews returns book with reviews attached, as nested reviews
+// don't repeat the reviewed book
+func WithReviews(book Book, reviews []books.Review) Book {
+	for _, r := range reviews {
+		review := FromReview(r)
+		review.Context, review.ItemReviewed = "", nil
+		book.Review = append(book.Review, review)
+	}
+
+	return book
+}
+
+// ValidationError lists the missing or invalid properties of a value
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "schemaorg: " + strings.Join(e.Problems, "; ")
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Problems: v.problems}
+}
+
+func (v *validator) book(path string, b *Book) {
+	if b == nil {
+		v.addf("%s is missing", path)
+		return
+	}
+	if strings.TrimSpace(b.Name) == "" {
+		v.addf("%s.name is required", path)
+	}
+	if len(b.Author) == 0 {
+		v.addf("%s.author is required", path)
+	}
+	for i, a := range b.Author {
+		if strings.TrimSpace(a.Name) == "" {
+			v.addf("%s.author[%d].name is required", path, i)
+		}
+	}
+	if b.ISBN != "" && !ValidISBN13(b.ISBN) {
+		v.addf("%s.isbn %q isn't a valid ISBN-13", path, b.ISBN)
+	}
+	for i := range b.Review {
+		v.review(fmt.Sprintf("%s.review[%d]", path, i), &b.Review[i], false)
+	}
+}
+
+func (v *validator) review(path string, r *Review, standalone bool) {
+	if r.Author == nil || strings.TrimSpace(r.Author.Name) == "" {
+		v.addf("%s.author.name is required", path)
+	}
+	if standalone {
+		if r.ItemReviewed == nil || strings.TrimSpace(r.ItemReviewed.Name) == "" {
+			v.addf("%s.itemReviewed.name is required", path)
+		}
+	}
+}
+
+// Validate checks the properties required of a Book
+func (b *Book) Validate() error {
+	var v validator
+	v.book("book", b)
+
+	return v.err()
+}
+
+// Validate checks the properties required of an ItemList and its books
+func (l *ItemList) Validate() error {
+	var v validator
+	if len(l.ItemListElement) == 0 {
+		v.addf("itemList.itemListElement is required")
+	}
+	seen := make(map[int]bool)
+	for i, item := range l.ItemListElement {
+		path := fmt.Sprintf("itemList.itemListElement[%d]", i)
+		if item.Position < 1 {
+			v.addf("%s.position must be positive", path)
+		} else if seen[item.Position] {
+			v.addf("%s.position %d is repeated", path, item.Position)
+		}
+		seen[item.Position] = true
+		v.book(path+".item", item.Item)
+	}
+
+	return v.err()
+}
+
+// Validate checks the properties required of a Review
+func (r *Review) Validate() error {
+	var v validator
+	v.review("review", r, true)
+
+	return v.err()
+}
+
+// Validator is implemented by Book, ItemList and Review
+type Validator interface {
+	Validate() error
+}
+
+// JSONLD validates v and returns its JSON-LD. Book values get the
+// schema.org @context when they are the top level value.
+func JSONLD(v Validator) ([]byte, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	if b, ok := v.(*Book); ok && b.Context == "" {
+		withContext := *b
+		withContext.Context = Context
+		v = &withContext
+	}
+
+	// Marshal escapes <, > and &, so the JSON can't close the script element
+	return json.Marshal(v)
+}
+
+// ScriptTag validates v and returns it in a script element, to embed in a page
+func ScriptTag(v Validator) (template.HTML, error) {
+	data, err := JSONLD(v)
+	if err != nil {
+		return "", err
+	}
+
+	return template.HTML(`<script type="application/ld+json">` + string(data) + `</script>`), nil
+}
+
+// ValidISBN13 reports whether isbn, hyphens aside, is an ISBN-13
+// with a valid check digit
+func ValidISBN13(isbn string) bool {
+	isbn = strings.Replace(isbn, "-", "", -1)
+	if len(isbn) != 13 {
+		return false
+	}
+	sum := 0
+	for i, r := range isbn {
+		if r < '0' || r > '9' {
+			return false
+		}
+		d := int(r - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return sum%10 == 0
+}
+
+// Meta is an Open Graph meta tag
+type Meta struct {
+	Property string
+	Content  string
+}
+
+// OpenGraph returns the Open Graph tags of a book. url is the canonical
+// URL of the page, which Open Graph requires.
+func OpenGraph(b Book, url string) []Meta {
+	meta := []Meta{
+		{"og:type", "book"},
+		{"og:title", b.Name},
+		{"og:url", url},
+	}
+	if b.Image != "" {
+		meta = append(meta, Meta{"og:image", b.Image})
+	}
+	if b.Description != "" {
+		meta = append(meta, Meta{"og:description", b.Description})
+	}
+	if b.ISBN != "" {
+		meta = append(meta, Meta{"book:isbn", b.ISBN})
+	}
+
+	return meta
+}
+
+// MetaTags renders Open Graph tags as HTML meta elements
+func MetaTags(meta []Meta) template.HTML {
+	var b strings.Builder
+	for _, m := range meta {
+		fmt.Fprintf(&b, "<meta property=\"%s\" content=\"%s\">\n", html.EscapeString(m.Property), html.EscapeString(m.Content))
+	}
+
+	return template.HTML(b.String())
+}
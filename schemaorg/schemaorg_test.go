@@ -0,0 +1,162 @@
+package schemaorg
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func loadJSON(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	data, err := ioutil.ReadFile("../testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromList(t *testing.T) {
+	var l books.ListByDate
+	loadJSON(t, "list_by_date.json", &l)
+	list := FromList(&l)
+	if err := list.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := JSONLD(&list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["@context"] != Context || got["@type"] != "ItemList" || got["numberOfItems"] != 1.0 {
+		t.Errorf("got %s", data)
+	}
+	item := got["itemListElement"].([]interface{})[0].(map[string]interface{})
+	book := item["item"].(map[string]interface{})
+	if item["position"] != 1.0 || book["name"] != "THE MARTIAN" || book["isbn"] != "9780553418026" {
+		t.Errorf("got item %v", item)
+	}
+	if book["image"] != "http://du.ec2.nytimes.com.s3.amazonaws.com/prd/books/9780804139038.jpg" {
+		t.Errorf("got image %v", book["image"])
+	}
+	wantAuthor := []interface{}{map[string]interface{}{"@type": "Person", "name": "Andy Weir"}}
+	if !reflect.DeepEqual(book["author"], wantAuthor) {
+		t.Errorf("got author %v", book["author"])
+	}
+}
+
+func TestFromOverviewList(t *testing.T) {
+	var o books.Overview
+	loadJSON(t, "overview.json", &o)
+	list := FromOverviewList(o.Results.Lists[0])
+	if err := list.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	authors := list.ItemListElement[0].Item.Author
+	if len(authors) != 2 || authors[0].Name != "Clive Cussler" || authors[1].Name != "Justin Scott" {
+		t.Errorf("got authors %v", authors)
+	}
+}
+
+func TestFromReview(t *testing.T) {
+	var reviews books.Reviews
+	loadJSON(t, "reviews.json", &reviews)
+	r := FromReview(reviews.Results[0])
+	if err := r.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Author.Name != "Janet Maslin" || r.ItemReviewed.Name != "1Q84" || r.ItemReviewed.ISBN != "9780307476463" {
+		t.Errorf("got %+v", r)
+	}
+
+	book := WithReviews(Book{Type: "Book", Name: "1Q84", Author: []Person{{"Person", "Haruki Murakami"}}}, reviews.Results)
+	data, err := JSONLD(&book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), `{"@context":"https://schema.org","@type":"Book"`) ||
+		strings.Contains(string(data), "itemReviewed") {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	list := ItemList{Type: "ItemList", ItemListElement: []ListItem{
+		{Type: "ListItem", Position: 1, Item: &Book{Type: "Book", Name: "A", Author: []Person{{"Person", "X"}}, ISBN: "9780804139039"}},
+		{Type: "ListItem", Position: 1, Item: &Book{Type: "Book"}},
+	}}
+	err := list.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a ValidationError", err)
+	}
+	want := []string{
+		`itemList.itemListElement[0].item.isbn "9780804139039" isn't a valid ISBN-13`,
+		`itemList.itemListElement[1].position 1 is repeated`,
+		`itemList.itemListElement[1].item.name is required`,
+		`itemList.itemListElement[1].item.author is required`,
+	}
+	if !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("got problems %q, want %q", verr.Problems, want)
+	}
+	if _, err := ScriptTag(&list); err == nil {
+		t.Error("ScriptTag accepted an invalid list")
+	}
+
+	if err := (&Review{Type: "Review"}).Validate(); err == nil {
+		t.Error("expected an error for a review without author or book")
+	}
+}
+
+func TestScriptTag(t *testing.T) {
+	b := Book{Type: "Book", Name: "</script><script>alert(1)</script>", Author: []Person{{"Person", "X"}}}
+	tag, err := ScriptTag(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := strings.TrimSuffix(strings.TrimPrefix(string(tag), `<script type="application/ld+json">`), "</script>")
+	if strings.Contains(body, "<") {
+		t.Errorf("JSON-LD can close its script element: %s", tag)
+	}
+}
+
+func TestValidISBN13(t *testing.T) {
+	cases := map[string]bool{
+		"9780804139038":     true,
+		"978-0-8041-3903-8": true,
+		"9780804139039":     false,
+		"080413903X":        false,
+		"97808041390a8":     false,
+	}
+	for isbn, want := range cases {
+		if got := ValidISBN13(isbn); got != want {
+			t.Errorf("ValidISBN13(%q) == %v, want %v", isbn, got, want)
+		}
+	}
+}
+
+func TestOpenGraph(t *testing.T) {
+	b := Book{Type: "Book", Name: `"Quoted" & <b>`, ISBN: "9780804139038", Image: "http://example.com/c.jpg"}
+	tags := string(MetaTags(OpenGraph(b, "https://example.com/books/9780804139038")))
+	for _, want := range []string{
+		`<meta property="og:type" content="book">`,
+		`<meta property="og:title" content="&#34;Quoted&#34; &amp; &lt;b&gt;">`,
+		`<meta property="og:url" content="https://example.com/books/9780804139038">`,
+		`<meta property="og:image" content="http://example.com/c.jpg">`,
+		`<meta property="book:isbn" content="9780804139038">`,
+	} {
+		if !strings.Contains(tags, want) {
+			t.Errorf("tags don't contain %s:\n%s", want, tags)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Package schematest helps tests catch drift between captured
+// Books API payloads and the Go models decoding them
+package schematest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// Check compares every payload file matching pattern with model,
+// e.g. Check(t, "testdata/overview*.json", books.Overview{}).
+//
+// Unknown fields fail the test, as the model would drop their data.
+// Missing fields are only logged, the API omitting empty fields at times.
+// Paths listed in allow, such as "results.books[].price", are ignored.
+func Check(t testing.TB, pattern string, model interface{}, allow ...string) {
+	t.Helper()
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatalf("schematest: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("schematest: no payload matches %s", pattern)
+	}
+
+	allowed := make(map[string]bool, len(allow))
+	for _, path := range allow {
+		allowed[path] = true
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("schematest: %v", err)
+		}
+		warnings, err := books.SchemaDrift(data, model)
+		if err != nil {
+			t.Errorf("schematest: %s: %v", file, err)
+			continue
+		}
+		for _, w := range warnings {
+			switch {
+			case allowed[w.Path]:
+			case w.Kind == books.UnknownField:
+				t.Errorf("%s: %v", file, w)
+			default:
+				t.Logf("%s: %v", file, w)
+			}
+		}
+	}
+}
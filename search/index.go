@@ -0,0 +1,496 @@
+// Package search is an embeddable full-text index over archived books
+// and reviews, ranked with BM25.
+//
+// Books are fed from ListByDate, ListHistory and Reviews responses and
+// merged by ISBN, so a search for "memoir about cooking" matches titles,
+// authors, descriptions and the summaries of the books' reviews.
+package search
+
+import (
+	"encoding/gob"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// Field is a searchable part of a Book
+type Field int
+
+// Searchable fields
+const (
+	Title Field = iota
+	Author
+	Description
+	Review
+	numFields
+)
+
+// DefaultBoosts weigh matches in a title or author above the others
+var DefaultBoosts = map[Field]float64{
+	Title:       3,
+	Author:      2,
+	Description: 1,
+	Review:      1,
+}
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Book is an indexed book
+type Book struct {
+	Title       string
+	Author      string
+	Publisher   string
+	Description string
+	AgeGroup    string
+	// ISBN13s holds every ISBN13 known for the book, primary one first
+	ISBN13s []string
+	// Lists are the names of the lists the book appeared on
+	Lists []string
+	// Reviews are the summaries of the book's NYT reviews
+	Reviews    []string
+	ReviewURLs []string
+}
+
+func (bk *Book) text(f Field) string {
+	switch f {
+	case Title:
+		return bk.Title
+	case Author:
+		return bk.Author
+	case Description:
+		return bk.Description
+	default:
+		return strings.Join(bk.Reviews, " ")
+	}
+}
+
+// Filters narrow down search results
+type Filters struct {
+	// List keeps the books that appeared on a list, by list name
+	List string
+	// Author keeps the books whose author contains this, case insensitively
+	Author string
+	// AgeGroup keeps the books of an age group
+	AgeGroup string
+	// Reviewed keeps the books with at least one review
+	Reviewed bool
+	// Limit is the maximum number of results, 10 if zero
+	Limit int
+}
+
+func (f Filters) match(bk *Book) bool {
+	if f.List != "" && !contains(bk.Lists, f.List) {
+		return false
+	}
+	if f.Author != "" && !strings.Contains(strings.ToLower(bk.Author), strings.ToLower(f.Author)) {
+		return false
+	}
+	if f.AgeGroup != "" && bk.AgeGroup != f.AgeGroup {
+		return false
+	}
+	if f.Reviewed && len(bk.Reviews) == 0 {
+		return false
+	}
+
+	return true
+}
+
+// clone copies bk, slices included
+func (bk *Book) clone() Book {
+	c := *bk
+	c.ISBN13s = append([]string(nil), bk.ISBN13s...)
+	c.Lists = append([]string(nil), bk.Lists...)
+	c.Reviews = append([]string(nil), bk.Reviews...)
+	c.ReviewURLs = append([]string(nil), bk.ReviewURLs...)
+
+	return c
+}
+
+// Result is a book matching a search. Its Book is a copy,
+// changing it doesn't change the index.
+type Result struct {
+	Book  Book
+	Score float64
+}
+
+type posting struct {
+	doc  int
+	freq int
+}
+
+// Index is an inverted index of books. It is safe for concurrent use.
+type Index struct {
+	// Boosts weigh each field's contribution to the score
+	Boosts map[Field]float64
+
+	mu     sync.RWMutex
+	docs   []*Book
+	byISBN map[string]int
+	byName map[string]int
+
+	// the postings are rebuilt on the first search after a change
+	dirty    bool
+	postings [numFields]map[string][]posting
+	lengths  [numFields][]int
+	avgLen   [numFields]float64
+}
+
+// New constructs an empty Index using DefaultBoosts
+func New() *Index {
+	boosts := make(map[Field]float64, len(DefaultBoosts))
+	for f, w := range DefaultBoosts {
+		boosts[f] = w
+	}
+
+	return &Index{
+		Boosts: boosts,
+		byISBN: make(map[string]int),
+		byName: make(map[string]int),
+	}
+}
+
+// Len returns the number of books in the index
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.docs)
+}
+
+// Add adds a book to the index, merging it with an already indexed book
+// sharing one of its ISBNs, or its title and author.
+func (ix *Index) Add(bk Book) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.add(bk)
+}
+
+func (ix *Index) add(bk Book) {
+	ix.dirty = true
+	name := strings.ToLower(bk.Title + "|" + bk.Author)
+
+	i, ok := -1, false
+	for _, isbn := range bk.ISBN13s {
+		if i, ok = ix.byISBN[isbn]; ok {
+			break
+		}
+	}
+	if !ok {
+		i, ok = ix.byName[name]
+	}
+	if !ok {
+		i = len(ix.docs)
+		ix.docs = append(ix.docs, &Book{})
+	}
+
+	doc := ix.docs[i]
+	if doc.Title == "" {
+		doc.Title = bk.Title
+	}
+	if doc.Author == "" {
+		doc.Author = bk.Author
+	}
+	if doc.Publisher == "" {
+		doc.Publisher = bk.Publisher
+	}
+	if doc.AgeGroup == "" {
+		doc.AgeGroup = bk.AgeGroup
+	}
+	// descriptions change over the weeks, keep the most complete one
+	if len(bk.Description) > len(doc.Description) {
+		doc.Description = bk.Description
+	}
+	doc.ISBN13s = appendUnique(doc.ISBN13s, bk.ISBN13s...)
+	doc.Lists = appendUnique(doc.Lists, bk.Lists...)
+	for k, url := range bk.ReviewURLs {
+		if contains(doc.ReviewURLs, url) {
+			continue
+		}
+		doc.ReviewURLs = append(doc.ReviewURLs, url)
+		if k < len(bk.Reviews) {
+			doc.Reviews = append(doc.Reviews, bk.Reviews[k])
+		}
+	}
+
+	for _, isbn := range doc.ISBN13s {
+		ix.byISBN[isbn] = i
+	}
+	if doc.Title != "" {
+		ix.byName[name] = i
+	}
+}
+
+// AddList indexes the books of a list
+func (ix *Index) AddList(list *books.ListByDate) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, b := range list.Results.Books {
+		isbns := nonEmpty(b.PrimaryISBN13)
+		for _, isbn := range b.ISBNs {
+			isbns = append(isbns, nonEmpty(isbn.ISBN13)...)
+		}
+		ix.add(Book{
+			Title:       b.Title,
+			Author:      b.Author,
+			Publisher:   b.Publisher,
+			Description: b.Description,
+			AgeGroup:    b.AgeGroup,
+			ISBN13s:     isbns,
+			Lists:       nonEmpty(list.Results.ListName),
+		})
+	}
+}
+
+// AddHistory indexes the books of a list history
+func (ix *Index) AddHistory(h *books.ListHistory) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, b := range h.Results {
+		var isbns, lists []string
+		for _, r := range b.RanksHistory {
+			isbns = append(isbns, nonEmpty(r.PrimaryISBN13)...)
+			lists = append(lists, nonEmpty(r.ListName)...)
+		}
+		for _, isbn := range b.ISBNs {
+			isbns = append(isbns, nonEmpty(isbn.ISBN13)...)
+		}
+		ix.add(Book{
+			Title:       b.Title,
+			Author:      b.Author,
+			Publisher:   b.Publisher,
+			Description: b.Description,
+			AgeGroup:    b.AgeGroup,
+			ISBN13s:     appendUnique(nil, isbns...),
+			Lists:       appendUnique(nil, lists...),
+		})
+	}
+}
+
+// AddReviews indexes reviews, attaching them to the reviewed books
+func (ix *Index) AddReviews(reviews *books.Reviews) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, r := range reviews.Results {
+		ix.add(Book{
+			Title:      r.BookTitle,
+			Author:     r.BookAuthor,
+			ISBN13s:    r.ISBN13,
+			Reviews:    []string{r.Summary},
+			ReviewURLs: []string{r.URL},
+		})
+	}
+}
+
+// Search returns the books best matching query, highest score first
+func (ix *Index) Search(query string, filters Filters) []Result {
+	ix.mu.Lock()
+	if ix.dirty {
+		ix.rebuild()
+	}
+	ix.mu.Unlock()
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	terms := Tokenize(query)
+	scores := make(map[int]float64)
+	n := float64(len(ix.docs))
+	for f := Field(0); f < numFields; f++ {
+		boost := ix.Boosts[f]
+		if boost == 0 || ix.avgLen[f] == 0 {
+			continue
+		}
+		for _, term := range terms {
+			postings := ix.postings[f][term]
+			if len(postings) == 0 {
+				continue
+			}
+			df := float64(len(postings))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for _, p := range postings {
+				tf := float64(p.freq)
+				norm := k1 * (1 - b + b*float64(ix.lengths[f][p.doc])/ix.avgLen[f])
+				scores[p.doc] += boost * idf * tf * (k1 + 1) / (tf + norm)
+			}
+		}
+	}
+
+	var results []Result
+	for doc, score := range scores {
+		if filters.match(ix.docs[doc]) {
+			results = append(results, Result{Book: ix.docs[doc].clone(), Score: score})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Book.Title < results[j].Book.Title
+	})
+
+	limit := filters.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// rebuild recomputes the postings from the documents
+func (ix *Index) rebuild() {
+	for f := Field(0); f < numFields; f++ {
+		ix.postings[f] = make(map[string][]posting)
+		ix.lengths[f] = make([]int, len(ix.docs))
+		total := 0
+		for i, doc := range ix.docs {
+			terms := Tokenize(doc.text(f))
+			ix.lengths[f][i] = len(terms)
+			total += len(terms)
+
+			freqs := make(map[string]int)
+			for _, t := range terms {
+				freqs[t]++
+			}
+			for t, freq := range freqs {
+				ix.postings[f][t] = append(ix.postings[f][t], posting{doc: i, freq: freq})
+			}
+		}
+		ix.avgLen[f] = 0
+		if len(ix.docs) > 0 {
+			ix.avgLen[f] = float64(total) / float64(len(ix.docs))
+		}
+	}
+	ix.dirty = false
+}
+
+// stopWords are left out of the index
+var stopWords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "her": true, "his": true, "in": true,
+	"into": true, "is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "their": true, "this": true, "to": true, "was": true,
+	"who": true, "with": true,
+}
+
+// Tokenize splits text into lowercase, stemmed terms, leaving out stop words
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	var terms []string
+	for _, w := range words {
+		w = strings.Trim(w, "'")
+		w = strings.TrimSuffix(w, "'s")
+		if w == "" || stopWords[w] {
+			continue
+		}
+		terms = append(terms, Stem(w))
+	}
+
+	return terms
+}
+
+// Save writes the indexed books and the boosts to w. The postings
+// aren't saved as they are rebuilt on the first search after Load.
+func (ix *Index) Save(w io.Writer) error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(ix.docs); err != nil {
+		return err
+	}
+
+	return enc.Encode(ix.Boosts)
+}
+
+// SaveFile saves the index to a file
+func (ix *Index) SaveFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := ix.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Load reads an index written by Save. Indexes saved without
+// their boosts get DefaultBoosts.
+func Load(r io.Reader) (*Index, error) {
+	dec := gob.NewDecoder(r)
+	var docs []*Book
+	if err := dec.Decode(&docs); err != nil {
+		return nil, err
+	}
+	var boosts map[Field]float64
+	if err := dec.Decode(&boosts); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	ix := New()
+	if boosts != nil {
+		ix.Boosts = boosts
+	}
+	for _, doc := range docs {
+		ix.add(*doc)
+	}
+
+	return ix, nil
+}
+
+// LoadFile loads an index saved with SaveFile
+func LoadFile(name string) (*Index, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendUnique(ss []string, add ...string) []string {
+	for _, s := range add {
+		if !contains(ss, s) {
+			ss = append(ss, s)
+		}
+	}
+
+	return ss
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return []string{s}
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+	listJSON := `{"results": {"list_name": "Hardcover Fiction", "books": [
+		{"rank": 1, "title": "FOURTH WING", "author": "Rebecca Yarros", "primary_isbn13": "9781649374042", "description": "Violet Sorrengail is urged by the commanding general, who also is her mother, to become a candidate for the elite dragon riders."},
+		{"rank": 2, "title": "THE MARTIAN", "author": "Andy Weir", "primary_isbn13": "9780553418026", "description": "Separated from his crew, an astronaut embarks on a quest to stay alive on Mars."},
+		{"rank": 3, "title": "DRAGON TEETH", "author": "Michael Crichton", "primary_isbn13": "9780062473356", "description": "Two paleontologists compete in the Wild West of 1876."}]}}`
+	historyJSON := `{"results": [{"title": "KITCHEN CONFIDENTIAL", "author": "Anthony Bourdain", "description": "A chef's memoir of life in restaurant kitchens.",
+		"isbns": [{"isbn13": "9780060899226"}], "ranks_history": [{"primary_isbn13": "9780060899226", "list_name": "Paperback Nonfiction"}]}]}`
+	reviewsJSON := `{"results": [
+		{"url": "http://www.nytimes.com/review/kitchen-confidential.html", "book_title": "Kitchen Confidential", "book_author": "Anthony Bourdain", "summary": "A raucous account of cooking for a living.", "isbn13": ["9780060899226"]},
+		{"url": "http://www.nytimes.com/review/the-martian.html", "book_title": "The Martian", "book_author": "Andy Weir", "summary": "Science saves the day.", "isbn13": ["9780553418026"]}]}`
+
+	var list books.ListByDate
+	var history books.ListHistory
+	var reviews books.Reviews
+	for s, v := range map[string]interface{}{listJSON: &list, historyJSON: &history, reviewsJSON: &reviews} {
+		if err := json.Unmarshal([]byte(s), v); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ix := New()
+	ix.AddList(&list)
+	ix.AddHistory(&history)
+	ix.AddReviews(&reviews)
+
+	return ix
+}
+
+func TestSearch(t *testing.T) {
+	ix := newTestIndex(t)
+	if got := ix.Len(); got != 4 {
+		t.Fatalf("got %d books, want 4 once reviews are merged", got)
+	}
+
+	// the title match ranks above the description match
+	results := ix.Search("dragons", Filters{})
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %+v", len(results), results)
+	}
+	if results[0].Book.Title != "DRAGON TEETH" || results[1].Book.Title != "FOURTH WING" {
+		t.Errorf("got %v then %v", results[0].Book.Title, results[1].Book.Title)
+	}
+
+	// review summaries are searchable and stemmed
+	results = ix.Search("memoir about cooking", Filters{})
+	if len(results) != 1 || results[0].Book.ISBN13s[0] != "9780060899226" {
+		t.Fatalf("got %+v, want Kitchen Confidential", results)
+	}
+	if len(results[0].Book.Reviews) != 1 {
+		t.Errorf("review not attached: %+v", results[0].Book)
+	}
+
+	results = ix.Search("dragon", Filters{Author: "crichton"})
+	if len(results) != 1 || results[0].Book.Title != "DRAGON TEETH" {
+		t.Errorf("got %+v, want DRAGON TEETH", results)
+	}
+	if results := ix.Search("dragon", Filters{List: "Paperback Nonfiction"}); len(results) != 0 {
+		t.Errorf("got %+v, want no results", results)
+	}
+	if results := ix.Search("mars", Filters{Reviewed: true}); len(results) != 1 {
+		t.Errorf("got %+v, want THE MARTIAN", results)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ix := newTestIndex(t)
+	ix.Boosts[Description] = 4
+	var buf bytes.Buffer
+	if err := ix.Save(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.Len() != ix.Len() {
+		t.Errorf("got %d books, want %d", loaded.Len(), ix.Len())
+	}
+
+	want := ix.Search("astronaut", Filters{})
+	got := loaded.Search("astronaut", Filters{})
+	if len(got) != 1 || got[0].Book.Title != want[0].Book.Title || got[0].Score != want[0].Score {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if loaded.Boosts[Description] != 4 {
+		t.Errorf("got boosts %v, want the saved ones", loaded.Boosts)
+	}
+}
+
+func TestLoadWithoutBoosts(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode([]*Book{{Title: "THE MARTIAN"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.Len() != 1 || loaded.Boosts[Title] != DefaultBoosts[Title] {
+		t.Errorf("got %d books and boosts %v", loaded.Len(), loaded.Boosts)
+	}
+}
+
+func TestResultsAreCopies(t *testing.T) {
+	ix := newTestIndex(t)
+	results := ix.Search("astronaut", Filters{})
+	if len(results) != 1 || len(results[0].Book.ISBN13s) == 0 {
+		t.Fatalf("got %+v", results)
+	}
+	results[0].Book.ISBN13s[0] = "changed"
+
+	if again := ix.Search("astronaut", Filters{}); again[0].Book.ISBN13s[0] == "changed" {
+		t.Error("changing a result changed the index")
+	}
+}
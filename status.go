@@ -0,0 +1,69 @@
+package books
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIError is a response with an error status, returned by
+// Clients made with WithStatusErrors
+type APIError struct {
+	StatusCode int
+	// Message is the explanation the API gave, or the status text
+	Message string
+	// RetryAfter is how long the API asked to wait before retrying,
+	// usually on 429 responses
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("books: %d %s", e.StatusCode, e.Message)
+}
+
+// WithStatusErrors makes the Client return an *APIError for responses
+// with a status of 400 or above, instead of decoding their body
+func WithStatusErrors() OptionFunc {
+	return func(c *Client) {
+		c.statusErrors = true
+	}
+}
+
+// maxErrorBody is the most read of an error response's body
+const maxErrorBody = 4 << 10
+
+// newAPIError reads the explanation out of an error response, the API
+// answering either with a gateway fault or a list of errors:
+//
+//	{"fault": {"faultstring": "Invalid ApiKey"}}
+//	{"status": "ERROR", "errors": ["Invalid date"]}
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+
+	var body struct {
+		Fault struct {
+			Faultstring string `json:"faultstring"`
+		} `json:"fault"`
+		Errors []string `json:"errors"`
+	}
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if json.Unmarshal(data, &body) != nil {
+		return e
+	}
+	switch {
+	case body.Fault.Faultstring != "":
+		e.Message = body.Fault.Faultstring
+	case len(body.Errors) > 0:
+		e.Message = strings.Join(body.Errors, "; ")
+	}
+
+	return e
+}
@@ -0,0 +1,79 @@
+package books
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   APIError
+	}{
+		{
+			name:   "fault",
+			status: http.StatusUnauthorized,
+			body:   `{"fault":{"faultstring":"Invalid ApiKey","detail":{"errorcode":"oauth.v2.InvalidApiKey"}}}`,
+			want:   APIError{StatusCode: 401, Message: "Invalid ApiKey"},
+		},
+		{
+			name:   "errors",
+			status: http.StatusBadRequest,
+			body:   `{"status":"ERROR","copyright":"","errors":["Invalid date","Unknown list"],"results":[]}`,
+			want:   APIError{StatusCode: 400, Message: "Invalid date; Unknown list"},
+		},
+		{
+			name:   "rate limited",
+			status: http.StatusTooManyRequests,
+			header: http.Header{"Retry-After": {"30"}},
+			body:   `<html>slow down</html>`,
+			want:   APIError{StatusCode: 429, Message: "Too Many Requests", RetryAfter: 30 * time.Second},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := &MockClient{
+				MockDo: func(*http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: tc.status,
+						Header:     tc.header,
+						Body:       ioutil.NopCloser(strings.NewReader(tc.body)),
+					}, nil
+				},
+			}
+			c := NewClient("apikey", WithHTTPClient(mc), WithStatusErrors())
+			_, err := c.GetBestSellersListNames()
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got %v, want an *APIError", err)
+			}
+			if *apiErr != tc.want {
+				t.Errorf("got %+v, want %+v", *apiErr, tc.want)
+			}
+		})
+	}
+}
+
+func TestStatusErrorsOff(t *testing.T) {
+	mc := &MockClient{
+		MockDo: func(*http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"status":"ERROR","errors":["nope"],"results":[]}`)),
+			}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+	if _, err := c.GetBestSellersListNames(); err != nil {
+		t.Errorf("got %v, want the body decoded without WithStatusErrors", err)
+	}
+}
@@ -0,0 +1,198 @@
+package books
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrResponseTooLarge is returned when a response body is larger
+// than the limit set with WithMaxResponseSize
+var ErrResponseTooLarge = errors.New("books: response too large")
+
+// limitedBody fails reads past n bytes with ErrResponseTooLarge,
+// unlike io.LimitReader which silently truncates
+type limitedBody struct {
+	io.ReadCloser
+	n     int64
+	limit int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// only fail if there actually is more to read
+		var probe [1]byte
+		k, err := l.ReadCloser.Read(probe[:])
+		if k > 0 {
+			return 0, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, l.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	k, err := l.ReadCloser.Read(p)
+	l.n -= int64(k)
+
+	return k, err
+}
+
+// StreamBestSellersListHistory Gets Best Sellers list history, calling fn for
+// every book as it is decoded instead of holding the whole response.
+// Returning an error from fn stops the stream and returns that error.
+func (c *Client) StreamBestSellersListHistory(qp QueryParam, fn func(HistoryBook) error) error {
+	return c.stream(context.Background(), HistoryEndpoint, qp, func(dec *json.Decoder) error {
+		return streamArray(dec, func() error {
+			var b HistoryBook
+			if err := dec.Decode(&b); err != nil {
+				return err
+			}
+			return fn(b)
+		})
+	})
+}
+
+// StreamFullOverview Gets all books for all the Best Sellers lists for specified date,
+// calling fn for every book as it is decoded. The list passed to fn has no Books,
+// and only carries the fields found before the books in the response.
+// Returning an error from fn stops the stream and returns that error.
+func (c *Client) StreamFullOverview(qp QueryParam, fn func(OverviewList, OverviewBook) error) error {
+	return c.stream(context.Background(), FullOverviewEndpoint, qp, func(dec *json.Decoder) error {
+		return streamObject(dec, func(key string) error {
+			if key != "lists" {
+				return skip(dec)
+			}
+			return streamArray(dec, func() error {
+				var list OverviewList
+				return streamObject(dec, func(key string) error {
+					if key == "books" {
+						return streamArray(dec, func() error {
+							var b OverviewBook
+							if err := dec.Decode(&b); err != nil {
+								return err
+							}
+							return fn(list, b)
+						})
+					}
+					return decodeListField(dec, key, &list)
+				})
+			})
+		})
+	})
+}
+
+// decodeListField decodes a single field of an overview list
+func decodeListField(dec *json.Decoder, key string, list *OverviewList) error {
+	switch key {
+	case "list_id":
+		return dec.Decode(&list.ListID)
+	case "list_name":
+		return dec.Decode(&list.ListName)
+	case "display_name":
+		return dec.Decode(&list.DisplayName)
+	case "updated":
+		return dec.Decode(&list.Updated)
+	case "list_image":
+		return dec.Decode(&list.ListImage)
+	default:
+		return skip(dec)
+	}
+}
+
+// stream gets endpoint and hands the decoder positioned on
+// the value of the response's "results" key to results. Streams are
+// never coalesced, as a shared call reads the whole body first.
+func (c *Client) stream(ctx context.Context, endpoint string, qp QueryParam, results func(*json.Decoder) error) error {
+	URL, err := c.makeLink(endpoint, qp)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(withEndpoint(ctx, endpoint), URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	return streamObject(dec, func(key string) error {
+		if key != "results" {
+			return skip(dec)
+		}
+		return results(dec)
+	})
+}
+
+// streamObject calls fn for every key of the object about to be decoded,
+// fn being responsible for consuming the key's value
+func streamObject(dec *json.Decoder, fn func(key string) error) error {
+	if ok, err := open(dec, '{'); !ok {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("books: unexpected %v, expected an object key", tok)
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
+
+// streamArray calls fn for every element of the array about to be decoded,
+// fn being responsible for consuming the element
+func streamArray(dec *json.Decoder, fn func() error) error {
+	if ok, err := open(dec, '['); !ok {
+		return err
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, ']')
+}
+
+// open consumes the opening delimiter of an object or array,
+// reporting false without an error for a null value
+func open(dec *json.Decoder, want json.Delim) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return false, fmt.Errorf("books: unexpected %v, expected %v", tok, want)
+	}
+
+	return true, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("books: unexpected %v, expected %v", tok, want)
+	}
+
+	return nil
+}
+
+// skip consumes the next value
+func skip(dec *json.Decoder) error {
+	var v json.RawMessage
+	return dec.Decode(&v)
+}
@@ -0,0 +1,134 @@
+package books
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	jsonData := `{"status": "OK", "copyright": "Copyright (c) 2019 The New York Times Company.  All Rights Reserved.", "num_results": 0}`
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			body := ioutil.NopCloser(bytes.NewReader([]byte(jsonData)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+
+	c := NewClient("apikey", WithHTTPClient(mc), WithMaxResponseSize(32))
+	if _, err := c.GetBestSellersListNames(); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("got error %v, want %v", err, ErrResponseTooLarge)
+	}
+
+	c = NewClient("apikey", WithHTTPClient(mc), WithMaxResponseSize(int64(len(jsonData))))
+	if _, err := c.GetBestSellersListNames(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestStreamBestSellersListHistory(t *testing.T) {
+	jsonData := `{"status": "OK", "num_results": 2, "results": [
+		{"title": "#GIRLBOSS", "author": "Sophia Amoruso", "ranks_history": [{"rank": 8, "list_name": "Business Books"}], "unknown": {"nested": [1, 2]}},
+		{"title": "THE MARTIAN", "author": "Andy Weir"}
+	], "copyright": "Copyright (c) 2019 The New York Times Company.  All Rights Reserved."}`
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			body := ioutil.NopCloser(bytes.NewReader([]byte(jsonData)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+
+	var titles []string
+	err := c.StreamBestSellersListHistory(nil, func(b HistoryBook) error {
+		titles = append(titles, b.Title)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(titles, ",") != "#GIRLBOSS,THE MARTIAN" {
+		t.Errorf("got titles %v", titles)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = c.StreamBestSellersListHistory(nil, func(b HistoryBook) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("got %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
+
+func TestStreamWithCoalescing(t *testing.T) {
+	// the body is written as the books are streamed,
+	// which a call reading it in full would never see
+	pr, pw := io.Pipe()
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			return &http.Response{Body: pr}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc), WithRequestCoalescing())
+
+	go io.WriteString(pw, `{"status": "OK", "results": [{"title": "#GIRLBOSS"}, `)
+	var titles []string
+	err := c.StreamBestSellersListHistory(nil, func(b HistoryBook) error {
+		titles = append(titles, b.Title)
+		if len(titles) == 1 {
+			go func() {
+				io.WriteString(pw, `{"title": "THE MARTIAN"}]}`)
+				pw.Close()
+			}()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(titles, ",") != "#GIRLBOSS,THE MARTIAN" {
+		t.Errorf("got titles %v", titles)
+	}
+}
+
+func TestStreamFullOverview(t *testing.T) {
+	jsonData := `{"status": "OK", "results": {"bestsellers_date": "2016-03-05", "lists": [
+		{"list_id": 704, "list_name": "Combined Print and E-Book Fiction", "books": [{"rank": 1, "title": "THE GANGSTER"}, {"rank": 2, "title": "NEST"}]},
+		{"list_id": 1, "list_name": "Hardcover Fiction", "books": null},
+		{"list_id": 2, "list_name": "Hardcover Nonfiction", "books": [{"rank": 1, "title": "GIRLBOSS"}]}
+	]}}`
+	var path string
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			path = r.URL.Path
+			body := ioutil.NopCloser(bytes.NewReader([]byte(jsonData)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+	c := NewClient("apikey", WithHTTPClient(mc))
+
+	var got []string
+	err := c.StreamFullOverview(nil, func(l OverviewList, b OverviewBook) error {
+		got = append(got, l.ListName+":"+b.Title)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "Combined Print and E-Book Fiction:THE GANGSTER,Combined Print and E-Book Fiction:NEST,Hardcover Nonfiction:GIRLBOSS"
+	if strings.Join(got, ",") != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if path != "/svc/books/v3"+FullOverviewEndpoint {
+		t.Errorf("got path %v", path)
+	}
+}
@@ -0,0 +1,205 @@
+package books
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WarningKind tells unknown fields from missing ones
+type WarningKind string
+
+// Kinds of DecodeWarning
+const (
+	// UnknownField is a field of the payload the model doesn't have
+	UnknownField WarningKind = "unknown"
+	// MissingField is a field of the model the payload doesn't have
+	MissingField WarningKind = "missing"
+)
+
+// DecodeWarning reports a difference between a payload and its Go model.
+// Path is the JSON path of the field, e.g. "results.books[].title".
+type DecodeWarning struct {
+	Path string
+	Kind WarningKind
+}
+
+func (w DecodeWarning) String() string {
+	return string(w.Kind) + " field " + w.Path
+}
+
+// WithStrictDecoding makes the Client check every response against
+// its model. Rather than failing, fields the model doesn't have, or
+// that the response lacks, are reported in the response's Warnings.
+func WithStrictDecoding() OptionFunc {
+	return func(c *Client) {
+		c.strict = true
+	}
+}
+
+// warner is implemented by the responses that carry warnings
+type warner interface {
+	setWarnings([]DecodeWarning)
+}
+
+func (l *List) setWarnings(w []DecodeWarning)        { l.Warnings = w }
+func (l *ListByDate) setWarnings(w []DecodeWarning)  { l.Warnings = w }
+func (h *ListHistory) setWarnings(w []DecodeWarning) { h.Warnings = w }
+func (n *Names) setWarnings(w []DecodeWarning)       { n.Warnings = w }
+func (o *Overview) setWarnings(w []DecodeWarning)    { o.Warnings = w }
+func (r *Reviews) setWarnings(w []DecodeWarning)     { r.Warnings = w }
+
+// decode decodes a response body into v, logging it if the Client has
+// a logger and reporting failures to the observer
+func (c *Client) decode(ctx context.Context, body io.Reader, v interface{}) error {
+	err := c.decodeLogged(ctx, body, v)
+	if ob, ok := body.(*observedBody); ok && err != nil {
+		ob.failDecode(err)
+	}
+
+	return err
+}
+
+func (c *Client) decodeLogged(ctx context.Context, body io.Reader, v interface{}) error {
+	if c.logger == nil {
+		return c.unmarshal(body, v)
+	}
+
+	snip := &snippet{}
+	err := c.unmarshal(io.TeeReader(body, snip), v)
+	if err != nil {
+		// the decoder may have given up before reading a whole snippet
+		io.Copy(snip, io.LimitReader(body, int64(snippetSize-len(snip.b))))
+	}
+	c.logDecode(ctx, v, snip, err)
+
+	return err
+}
+
+// unmarshal decodes a response body into v, checking it in strict mode
+func (c *Client) unmarshal(body io.Reader, v interface{}) error {
+	if !c.strict {
+		return json.NewDecoder(body).Decode(v)
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	// unknown fields are reported as warnings rather than failing,
+	// so the body is decoded leniently and then checked
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return err
+	}
+
+	warnings, err := SchemaDrift(data, v)
+	if err != nil {
+		return err
+	}
+	if w, ok := v.(warner); ok {
+		w.setWarnings(warnings)
+	}
+
+	return nil
+}
+
+// SchemaDrift compares a JSON payload with the model it is decoded into,
+// v being a pointer to or a value of the model's type. Warnings are
+// sorted by path, and array elements share the path of their array.
+func SchemaDrift(data []byte, v interface{}) ([]DecodeWarning, error) {
+	var payload interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[DecodeWarning]bool)
+	drift("", payload, reflect.TypeOf(v), seen)
+
+	warnings := make([]DecodeWarning, 0, len(seen))
+	for w := range seen {
+		warnings = append(warnings, w)
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].Path != warnings[j].Path {
+			return warnings[i].Path < warnings[j].Path
+		}
+		return warnings[i].Kind < warnings[j].Kind
+	})
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+
+	return warnings, nil
+}
+
+func drift(path string, payload interface{}, t reflect.Type, seen map[DecodeWarning]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := payload.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for key, val := range obj {
+			field, ok := fields[key]
+			if !ok {
+				seen[DecodeWarning{Path: join(path, key), Kind: UnknownField}] = true
+				continue
+			}
+			drift(join(path, key), val, field.Type, seen)
+		}
+		for key := range fields {
+			if _, ok := obj[key]; !ok {
+				seen[DecodeWarning{Path: join(path, key), Kind: MissingField}] = true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := payload.([]interface{})
+		if !ok {
+			return
+		}
+		for _, elem := range arr {
+			drift(path+"[]", elem, t.Elem(), seen)
+		}
+	}
+}
+
+// jsonFields maps the JSON keys of a struct's fields to the fields
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		fields[name] = f
+	}
+
+	return fields
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,58 @@
+package books
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	jsonData := `{"status": "OK", "num_results": 1, "results": {"lists": [
+		{"list_id": 704, "list_name": "Combined Print and E-Book Fiction", "display_name": "Combined Print & E-Book Fiction", "updated": "WEEKLY", "list_image": "", "list_image_width": 128,
+		 "books": [{"rank": 1, "title": "THE GANGSTER", "book_uri": "nyt://book/1"}, {"rank": 2, "title": "NEST", "book_uri": "nyt://book/2"}]}
+	]}}`
+	mc := &MockClient{
+		func(r *http.Request) (*http.Response, error) {
+			body := ioutil.NopCloser(bytes.NewReader([]byte(jsonData)))
+
+			return &http.Response{Body: body}, nil
+		},
+	}
+
+	c := NewClient("apikey", WithHTTPClient(mc), WithStrictDecoding())
+	got, err := c.GetOverview(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Results.Lists[0].Books[1].Title != "NEST" {
+		t.Errorf("response not decoded: %+v", got)
+	}
+
+	var unknown []string
+	for _, w := range got.Warnings {
+		if w.Kind == UnknownField {
+			unknown = append(unknown, w.Path)
+		}
+	}
+	want := []string{"results.lists[].books[].book_uri", "results.lists[].list_image_width"}
+	if !reflect.DeepEqual(unknown, want) {
+		t.Errorf("got unknown fields %v, want %v", unknown, want)
+	}
+
+	missing := DecodeWarning{Path: "copyright", Kind: MissingField}
+	found := false
+	for _, w := range got.Warnings {
+		found = found || w == missing
+	}
+	if !found {
+		t.Errorf("missing copyright not reported in %v", got.Warnings)
+	}
+
+	// without strict decoding, no warnings are collected
+	c = NewClient("apikey", WithHTTPClient(mc))
+	if got, _ := c.GetOverview(nil); got.Warnings != nil {
+		t.Errorf("got warnings %v, want none", got.Warnings)
+	}
+}
@@ -0,0 +1,346 @@
+// Package watch polls the best sellers lists and reports the changes
+// between their editions as events, to Go callbacks or webhooks.
+//
+//	w := watch.New(c,
+//		watch.WithLists("hardcover-fiction", "hardcover-nonfiction"),
+//		watch.OnEvent(func(e watch.Event) { log.Println(e) }),
+//		watch.WithWebhook(watch.Webhook{URL: "https://example.com/hook", Secret: secret}),
+//	)
+//	err := w.Run(ctx)
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// EventKind is the kind of change an Event reports
+type EventKind string
+
+// Kinds of Event
+const (
+	// NewEdition is a list publishing a new edition
+	NewEdition EventKind = "new_edition"
+	// RankChange is a book moving up or down a list
+	RankChange EventKind = "rank_change"
+	// NewEntry is a book entering a list
+	NewEntry EventKind = "new_entry"
+	// DropOff is a book leaving a list
+	DropOff EventKind = "drop_off"
+)
+
+// Event is a change to a best sellers list
+type Event struct {
+	Kind EventKind `json:"kind"`
+	// List is the encoded name of the list, e.g. hardcover-fiction
+	List          string `json:"list"`
+	DisplayName   string `json:"display_name"`
+	PublishedDate string `json:"published_date"`
+	// Corrected is set for changes to an edition already seen,
+	// rather than between two editions
+	Corrected bool `json:"corrected,omitempty"`
+
+	// the book, unless Kind is NewEdition
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	ISBN13 string `json:"isbn13,omitempty"`
+	// Rank is 0 for a DropOff, and PreviousRank is 0 for a NewEntry
+	Rank         int `json:"rank,omitempty"`
+	PreviousRank int `json:"previous_rank,omitempty"`
+
+	Time time.Time `json:"time"`
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case NewEdition:
+		return fmt.Sprintf("%s: new edition %s", e.List, e.PublishedDate)
+	case RankChange:
+		return fmt.Sprintf("%s: %q moved from #%d to #%d", e.List, e.Title, e.PreviousRank, e.Rank)
+	case NewEntry:
+		return fmt.Sprintf("%s: %q entered at #%d", e.List, e.Title, e.Rank)
+	default:
+		return fmt.Sprintf("%s: %q dropped off from #%d", e.List, e.Title, e.PreviousRank)
+	}
+}
+
+// Source is what the Watcher polls, implemented by *books.Client
+type Source interface {
+	GetBestSellersListNames() (*books.Names, error)
+	GetBestSellersListByDate(date, listName string, qp books.QueryParam) (*books.ListByDate, error)
+}
+
+// Clock tells the time and schedules the polls, so that tests can control it
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Option configures a Watcher
+type Option func(*Watcher)
+
+// WithInterval sets the time between polls, an hour by default
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithClock replaces the system clock
+func WithClock(c Clock) Option {
+	return func(w *Watcher) {
+		w.clock = c
+	}
+}
+
+// WithLists restricts the watched lists to the ones with these encoded
+// names. Every list is watched by default.
+func WithLists(names ...string) Option {
+	return func(w *Watcher) {
+		w.lists = make(map[string]bool, len(names))
+		for _, n := range names {
+			w.lists[n] = true
+		}
+	}
+}
+
+// OnEvent adds a callback called with every event, in order
+func OnEvent(fn func(Event)) Option {
+	return func(w *Watcher) {
+		w.handlers = append(w.handlers, fn)
+	}
+}
+
+// OnError sets a callback called with the errors of the polls made by Run
+func OnError(fn func(error)) Option {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+// snapshot is the last seen edition of a list
+type snapshot struct {
+	publishedDate string
+	lastModified  string
+	books         map[string]books.ListBook
+}
+
+// Watcher polls the best sellers lists for changes
+type Watcher struct {
+	source   Source
+	interval time.Duration
+	clock    Clock
+	lists    map[string]bool
+	handlers []func(Event)
+	onError  func(error)
+	delivery delivery
+
+	mu        sync.Mutex
+	snapshots map[string]*snapshot
+
+	// dispatch keeps the deliveries of successive polls in order
+	dispatch sync.Mutex
+}
+
+// New constructs a Watcher polling source
+func New(source Source, options ...Option) *Watcher {
+	w := &Watcher{
+		source:    source,
+		interval:  time.Hour,
+		clock:     realClock{},
+		delivery:  newDelivery(),
+		snapshots: make(map[string]*snapshot),
+	}
+	for _, option := range options {
+		option(w)
+	}
+
+	return w
+}
+
+// Run polls the lists every interval until ctx is done
+func (w *Watcher) Run(ctx context.Context) error {
+	for {
+		if _, err := w.Poll(ctx); err != nil && w.onError != nil {
+			w.onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-w.clock.After(w.interval):
+		}
+	}
+}
+
+// Poll gets the current edition of every watched list and delivers the
+// events found since the previous poll, which it returns. The first poll
+// of a list only records it. Lists that fail to be fetched, or that the
+// API answers with an error payload, are left as they were and retried
+// at the next poll.
+//
+// Events are delivered once the lists are fetched, without holding up
+// other polls, but deliveries of successive polls happen in order.
+func (w *Watcher) Poll(ctx context.Context) ([]Event, error) {
+	events, failed, total, err := w.collect(ctx)
+	if err != nil {
+		return events, err
+	}
+	if len(events) > 0 {
+		// collect took it for us
+		defer w.dispatch.Unlock()
+	}
+	var problems []string
+	if len(failed) > 0 {
+		problems = append(problems, fmt.Sprintf("%d of %d lists failed: %s", len(failed), total, strings.Join(failed, "; ")))
+	}
+	for _, e := range events {
+		for _, fn := range w.handlers {
+			fn(e)
+		}
+		if err := w.delivery.deliver(ctx, w.clock, e); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return events, errors.New("watch: " + strings.Join(problems, "; "))
+	}
+
+	return events, nil
+}
+
+// collect fetches the watched lists and records their editions,
+// returning the events found and the lists that failed. When there
+// are events, it returns with w.dispatch held, taken before letting
+// go of w.mu so that the next poll can't deliver first.
+func (w *Watcher) collect(ctx context.Context) (events []Event, failed []string, total int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names, err := w.source.GetBestSellersListNames()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if names.Status != "OK" {
+		return nil, nil, 0, fmt.Errorf("watch: list names: status %q", names.Status)
+	}
+
+	for _, res := range names.Results {
+		name := res.ListNameEncoded
+		if w.lists != nil && !w.lists[name] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return events, nil, total, err
+		}
+		total++
+		list, err := w.source.GetBestSellersListByDate("current", name, nil)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		// a client without WithStatusErrors decodes error payloads,
+		// which mustn't be taken for an empty edition
+		if list.Status != "OK" || list.Results.PublishedDate == "" {
+			failed = append(failed, fmt.Sprintf("%s: status %q", name, list.Status))
+			continue
+		}
+		events = append(events, w.update(name, res.DisplayName, list)...)
+	}
+	if len(events) > 0 {
+		w.dispatch.Lock()
+	}
+
+	return events, failed, total, nil
+}
+
+// update records the edition of a list, returning its changes
+func (w *Watcher) update(name, displayName string, list *books.ListByDate) []Event {
+	current := &snapshot{
+		publishedDate: list.Results.PublishedDate,
+		lastModified:  list.LastModified,
+		books:         make(map[string]books.ListBook, len(list.Results.Books)),
+	}
+	var order []string
+	for _, b := range list.Results.Books {
+		key := bookKey(b)
+		current.books[key] = b
+		order = append(order, key)
+	}
+
+	previous := w.snapshots[name]
+	w.snapshots[name] = current
+	if previous == nil {
+		return nil
+	}
+	edition := current.publishedDate != previous.publishedDate
+	if !edition && current.lastModified == previous.lastModified {
+		return nil
+	}
+
+	now := w.clock.Now()
+	event := func(kind EventKind) Event {
+		return Event{
+			Kind:          kind,
+			List:          name,
+			DisplayName:   displayName,
+			PublishedDate: current.publishedDate,
+			Corrected:     !edition,
+			Time:          now,
+		}
+	}
+	withBook := func(e Event, b books.ListBook) Event {
+		e.Title, e.Author, e.ISBN13 = b.Title, b.Author, b.PrimaryISBN13
+		return e
+	}
+
+	var events []Event
+	if edition {
+		events = append(events, event(NewEdition))
+	}
+	for _, key := range order {
+		b := current.books[key]
+		old, ok := previous.books[key]
+		switch {
+		case !ok:
+			e := withBook(event(NewEntry), b)
+			e.Rank = b.Rank
+			events = append(events, e)
+		case old.Rank != b.Rank:
+			e := withBook(event(RankChange), b)
+			e.Rank, e.PreviousRank = b.Rank, old.Rank
+			events = append(events, e)
+		}
+	}
+	var drops []Event
+	for key, b := range previous.books {
+		if _, ok := current.books[key]; !ok {
+			e := withBook(event(DropOff), b)
+			e.PreviousRank = b.Rank
+			drops = append(drops, e)
+		}
+	}
+	sort.Slice(drops, func(i, j int) bool { return drops[i].PreviousRank < drops[j].PreviousRank })
+
+	return append(events, drops...)
+}
+
+// bookKey identifies a book across editions
+func bookKey(b books.ListBook) string {
+	if b.PrimaryISBN13 != "" {
+		return b.PrimaryISBN13
+	}
+
+	return strings.ToLower(b.Title + "|" + b.Author)
+}
@@ -0,0 +1,279 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// fakeClock only moves when told to
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []chan time.Time
+	waiting chan struct{}
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		waiting: make(chan struct{}, 16),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, ch)
+	c.waiting <- struct{}{}
+
+	return ch
+}
+
+// advance moves the clock, firing every pending After
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, ch := range c.waiters {
+		ch <- c.now
+	}
+	c.waiters = nil
+}
+
+type fakeSource struct {
+	mu    sync.Mutex
+	lists map[string]*books.ListByDate
+	err   error
+}
+
+func (s *fakeSource) GetBestSellersListNames() (*books.Names, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := &books.Names{Status: "OK"}
+	for _, name := range []string{"hardcover-fiction", "picture-books"} {
+		if _, ok := s.lists[name]; !ok {
+			continue
+		}
+		names.Results = append(names.Results, struct {
+			ListName            string `json:"list_name"`
+			DisplayName         string `json:"display_name"`
+			ListNameEncoded     string `json:"list_name_encoded"`
+			OldestPublishedDate string `json:"oldest_published_date"`
+			NewestPublishedDate string `json:"newest_published_date"`
+			Updated             string `json:"updated"`
+		}{ListNameEncoded: name, DisplayName: name})
+	}
+
+	return names, nil
+}
+
+func (s *fakeSource) GetBestSellersListByDate(date, listName string, qp books.QueryParam) (*books.ListByDate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s.lists[listName], nil
+}
+
+func (s *fakeSource) set(name string, l *books.ListByDate) {
+	s.mu.Lock()
+	s.lists[name] = l
+	s.mu.Unlock()
+}
+
+func edition(published, modified string, titles ...string) *books.ListByDate {
+	l := &books.ListByDate{Status: "OK", LastModified: modified}
+	l.Results.PublishedDate = published
+	for i, t := range titles {
+		l.Results.Books = append(l.Results.Books, books.ListBook{Rank: i + 1, Title: t, PrimaryISBN13: "isbn-" + t})
+	}
+
+	return l
+}
+
+func kinds(events []Event) []string {
+	var out []string
+	for _, e := range events {
+		out = append(out, string(e.Kind)+" "+e.Title)
+	}
+
+	return out
+}
+
+func TestPoll(t *testing.T) {
+	src := &fakeSource{lists: map[string]*books.ListByDate{
+		"hardcover-fiction": edition("2020-01-05", "m1", "A", "B", "C"),
+		"picture-books":     edition("2020-01-05", "m1", "X"),
+	}}
+	var got []Event
+	w := New(src, WithClock(newFakeClock()), WithLists("hardcover-fiction"), OnEvent(func(e Event) {
+		got = append(got, e)
+	}))
+
+	events, err := w.Poll(context.Background())
+	if err != nil || len(events) != 0 {
+		t.Fatalf("first poll returned %v, %v, want no events", events, err)
+	}
+
+	src.set("hardcover-fiction", edition("2020-01-12", "m2", "B", "A", "D"))
+	events, err = w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"new_edition ", "rank_change B", "rank_change A", "new_entry D", "drop_off C"}
+	if !reflect.DeepEqual(kinds(events), want) {
+		t.Errorf("got events %v, want %v", kinds(events), want)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("callback got %v, want %v", got, events)
+	}
+	if events[1].PreviousRank != 2 || events[1].Rank != 1 || events[1].Corrected {
+		t.Errorf("got %+v, want B moving from #2 to #1", events[1])
+	}
+
+	// the same edition, corrected
+	src.set("hardcover-fiction", edition("2020-01-12", "m3", "B", "A", "E"))
+	events, _ = w.Poll(context.Background())
+	want = []string{"new_entry E", "drop_off D"}
+	if !reflect.DeepEqual(kinds(events), want) {
+		t.Errorf("got events %v, want %v", kinds(events), want)
+	}
+	for _, e := range events {
+		if !e.Corrected {
+			t.Errorf("%v isn't marked as a correction", e)
+		}
+	}
+
+	events, _ = w.Poll(context.Background())
+	if len(events) != 0 {
+		t.Errorf("got events %v for an unchanged list", kinds(events))
+	}
+}
+
+func TestPollError(t *testing.T) {
+	src := &fakeSource{
+		lists: map[string]*books.ListByDate{"hardcover-fiction": edition("2020-01-05", "m1", "A")},
+		err:   errors.New("quota exceeded"),
+	}
+	w := New(src)
+	if _, err := w.Poll(context.Background()); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestPollErrorPayload(t *testing.T) {
+	src := &fakeSource{lists: map[string]*books.ListByDate{"hardcover-fiction": edition("2020-01-05", "m1", "A", "B")}}
+	w := New(src, WithClock(newFakeClock()))
+	w.Poll(context.Background())
+
+	// what a client without WithStatusErrors decodes from a 429
+	src.set("hardcover-fiction", &books.ListByDate{})
+	events, err := w.Poll(context.Background())
+	if err == nil || len(events) != 0 {
+		t.Errorf("got %v, %v for an error payload, want a failed list and no events", kinds(events), err)
+	}
+
+	src.set("hardcover-fiction", edition("2020-01-05", "m1", "A", "B"))
+	if events, err := w.Poll(context.Background()); err != nil || len(events) != 0 {
+		t.Errorf("got %v, %v after the error, want no events", kinds(events), err)
+	}
+}
+
+// editionSource publishes a new edition every time it is asked for the list
+type editionSource struct {
+	fakeSource
+	n int32
+}
+
+func (s *editionSource) GetBestSellersListByDate(date, listName string, qp books.QueryParam) (*books.ListByDate, error) {
+	n := atomic.AddInt32(&s.n, 1)
+	return edition(fmt.Sprintf("2020-%04d", n), "m", "A"), nil
+}
+
+func TestOverlappingPollsDeliverInOrder(t *testing.T) {
+	src := &editionSource{fakeSource: fakeSource{lists: map[string]*books.ListByDate{"hardcover-fiction": nil}}}
+	var delivered []string
+	w := New(src, WithClock(newFakeClock()), OnEvent(func(e Event) {
+		delivered = append(delivered, e.PublishedDate)
+		runtime.Gosched()
+	}))
+	w.Poll(context.Background())
+
+	// a poll with events hands w.mu over to w.dispatch,
+	// so that no other poll gets in between
+	events, _, _, err := w.collect(context.Background())
+	if err != nil || len(events) == 0 {
+		t.Fatalf("got %v, %v, want events", kinds(events), err)
+	}
+	if w.dispatch.TryLock() {
+		t.Fatal("collect returned events without holding dispatch")
+	}
+	w.dispatch.Unlock()
+	delivered = nil
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Poll(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if len(delivered) != 100 || !sort.StringsAreSorted(delivered) {
+		t.Errorf("got editions delivered in the order %v", delivered)
+	}
+}
+
+func TestRun(t *testing.T) {
+	src := &fakeSource{lists: map[string]*books.ListByDate{
+		"hardcover-fiction": edition("2020-01-05", "m1", "A"),
+	}}
+	clock := newFakeClock()
+	events := make(chan Event, 8)
+	w := New(src, WithClock(clock), WithInterval(time.Hour), OnEvent(func(e Event) { events <- e }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	<-clock.waiting
+	src.set("hardcover-fiction", edition("2020-01-12", "m2", "A"))
+	clock.advance(time.Hour)
+	<-clock.waiting
+
+	select {
+	case e := <-events:
+		if e.Kind != NewEdition || !e.Time.Equal(clock.Now()) {
+			t.Errorf("got %+v, want a new edition at %v", e, clock.Now())
+		}
+	default:
+		t.Error("no event after the interval")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+}
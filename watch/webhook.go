@@ -0,0 +1,207 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// SignatureHeader is the header carrying the signature of webhook payloads
+const SignatureHeader = "X-Nytbooks-Signature"
+
+// Webhook is a URL events are POSTed to as JSON
+type Webhook struct {
+	URL string
+	// Secret signs the payloads in the SignatureHeader, if set
+	Secret string
+}
+
+// Sign returns the signature of a webhook payload: "sha256=" followed by
+// the hex encoded HMAC-SHA256 of body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body,
+// for receivers of webhooks
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// WithWebhook adds a webhook every event is delivered to
+func WithWebhook(hook Webhook) Option {
+	return func(w *Watcher) {
+		w.delivery.webhooks = append(w.delivery.webhooks, hook)
+	}
+}
+
+// WithHTTPClient replaces the client webhooks are delivered with
+func WithHTTPClient(doer books.Doer) Option {
+	return func(w *Watcher) {
+		w.delivery.doer = doer
+	}
+}
+
+// WithRetries sets how many times a webhook delivery is attempted,
+// 3 by default, and the wait before the first retry, doubled after
+// each one, a second by default.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(w *Watcher) {
+		w.delivery.attempts = attempts
+		w.delivery.backoff = backoff
+	}
+}
+
+// WithDeadLetter appends the events that couldn't be delivered to a
+// webhook to the file name, one JSON DeadLetter per line. Without it,
+// failed deliveries are reported in the errors of Poll.
+func WithDeadLetter(name string) Option {
+	return func(w *Watcher) {
+		w.delivery.deadLetter = name
+	}
+}
+
+// DeadLetter is an event that couldn't be delivered to a webhook
+type DeadLetter struct {
+	Webhook  string    `json:"webhook"`
+	Event    Event     `json:"event"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// delivery posts events to webhooks
+type delivery struct {
+	webhooks   []Webhook
+	doer       books.Doer
+	attempts   int
+	backoff    time.Duration
+	deadLetter string
+
+	mu sync.Mutex
+}
+
+func newDelivery() delivery {
+	return delivery{
+		doer:     http.DefaultClient,
+		attempts: 3,
+		backoff:  time.Second,
+	}
+}
+
+// deliver posts e to every webhook, retrying failed posts
+// and dead lettering the ones still failing. It returns the
+// errors writing dead letters, or the failed deliveries if
+// there is no dead letter file.
+func (d *delivery) deliver(ctx context.Context, clock Clock, e Event) error {
+	if len(d.webhooks) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+
+	for _, hook := range d.webhooks {
+		backoff := d.backoff
+		attempt := 1
+		err := d.post(ctx, hook, body)
+		for ; err != nil && attempt < d.attempts; attempt++ {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-clock.After(backoff):
+				backoff *= 2
+				err = d.post(ctx, hook, body)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if err == nil {
+			continue
+		}
+		if d.deadLetter == "" {
+			failed = append(failed, fmt.Sprintf("delivering %s to %s: %v", e, hook.URL, err))
+			continue
+		}
+		letter := DeadLetter{
+			Webhook:  hook.URL,
+			Event:    e,
+			Error:    err.Error(),
+			Attempts: attempt,
+			FailedAt: clock.Now(),
+		}
+		if err := d.dead(letter); err != nil {
+			failed = append(failed, fmt.Sprintf("dead lettering %s for %s: %v", e, hook.URL, err))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (d *delivery) post(ctx context.Context, hook Webhook, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	}
+
+	resp, err := d.doer.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("watch: webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// dead appends a dead letter to the dead letter file
+func (d *delivery) dead(letter DeadLetter) error {
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, err := os.OpenFile(d.deadLetter, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,184 @@
+package watch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+// instantClock fires every After at once
+type instantClock struct{}
+
+func (instantClock) Now() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+func (instantClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func TestWebhook(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !Verify("s3cret", body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil || e.Kind != NewEdition {
+			t.Errorf("got event %+v, %v", e, err)
+		}
+		// fail the first attempt
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	src := &fakeSource{lists: map[string]*books.ListByDate{"hardcover-fiction": edition("2020-01-05", "m1")}}
+	w := New(src, WithClock(instantClock{}), WithWebhook(Webhook{URL: srv.URL, Secret: "s3cret"}))
+	w.Poll(context.Background())
+	src.set("hardcover-fiction", edition("2020-01-12", "m2"))
+	w.Poll(context.Background())
+
+	if calls != 2 {
+		t.Errorf("webhook called %d times, want 2", calls)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	name := filepath.Join(t.TempDir(), "dead.jsonl")
+	src := &fakeSource{lists: map[string]*books.ListByDate{"hardcover-fiction": edition("2020-01-05", "m1", "A")}}
+	w := New(src,
+		WithClock(instantClock{}),
+		WithWebhook(Webhook{URL: srv.URL}),
+		WithRetries(2, time.Second),
+		WithDeadLetter(name),
+	)
+	w.Poll(context.Background())
+	src.set("hardcover-fiction", edition("2020-01-12", "m2", "A"))
+	w.Poll(context.Background())
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var letters []DeadLetter
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var l DeadLetter
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatal(err)
+		}
+		letters = append(letters, l)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("got %d dead letters, want 1", len(letters))
+	}
+	if l := letters[0]; l.Webhook != srv.URL || l.Attempts != 2 || l.Event.Kind != NewEdition {
+		t.Errorf("got dead letter %+v", l)
+	}
+}
+
+func TestSign(t *testing.T) {
+	sig := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	want := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	if sig != want {
+		t.Errorf("Sign == %s, want %s", sig, want)
+	}
+	if Verify("other", []byte("x"), Sign("key", []byte("x"))) {
+		t.Error("Verify accepted a signature made with another secret")
+	}
+}
+
+func TestSlowWebhookDoesntBlockPolls(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	src := &fakeSource{lists: map[string]*books.ListByDate{"hardcover-fiction": edition("2020-01-05", "m1")}}
+	w := New(src, WithClock(instantClock{}), WithWebhook(Webhook{URL: srv.URL}))
+	w.Poll(context.Background())
+	src.set("hardcover-fiction", edition("2020-01-12", "m2"))
+	go w.Poll(context.Background())
+	<-entered
+
+	done := make(chan error)
+	go func() {
+		_, err := w.Poll(context.Background())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll blocked by a webhook delivery")
+	}
+}
+
+func TestDeadLetterWriteError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	name := filepath.Join(t.TempDir(), "missing", "dead.jsonl")
+	src := &fakeSource{lists: map[string]*books.ListByDate{"hardcover-fiction": edition("2020-01-05", "m1")}}
+	w := New(src,
+		WithClock(instantClock{}),
+		WithWebhook(Webhook{URL: srv.URL}),
+		WithRetries(1, time.Second),
+		WithDeadLetter(name),
+	)
+	w.Poll(context.Background())
+	src.set("hardcover-fiction", edition("2020-01-12", "m2"))
+	events, err := w.Poll(context.Background())
+
+	if len(events) != 1 {
+		t.Errorf("got %d events, want 1", len(events))
+	}
+	if err == nil || !strings.Contains(err.Error(), "dead lettering") {
+		t.Errorf("got %v, want the dead letter write failure", err)
+	}
+}
+
+func TestFailedDeliveryWithoutDeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	src := &fakeSource{lists: map[string]*books.ListByDate{"hardcover-fiction": edition("2020-01-05", "m1")}}
+	w := New(src, WithClock(instantClock{}), WithWebhook(Webhook{URL: srv.URL}), WithRetries(2, time.Second))
+	w.Poll(context.Background())
+	src.set("hardcover-fiction", edition("2020-01-12", "m2"))
+	_, err := w.Poll(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("got %v, want the failed delivery", err)
+	}
+}
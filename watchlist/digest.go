@@ -0,0 +1,96 @@
+package watchlist
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/smtp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteDigest writes matches as a plain text digest, grouped by list
+func WriteDigest(w io.Writer, title string, matches []Match) error {
+	sorted := make([]Match, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].DisplayName != sorted[j].DisplayName {
+			return sorted[i].DisplayName < sorted[j].DisplayName
+		}
+		return sorted[i].Rank < sorted[j].Rank
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\n%s\n", title, strings.Repeat("=", len(title)))
+	if len(sorted) == 0 {
+		fmt.Fprintln(tw, "\nNothing on the lists.")
+	}
+	for i, m := range sorted {
+		if i == 0 || m.DisplayName != sorted[i-1].DisplayName {
+			fmt.Fprintf(tw, "\n%s\n", m.DisplayName)
+		}
+		badge := ""
+		if m.FirstAppearance {
+			badge = "NEW"
+		}
+		fmt.Fprintf(tw, "  #%d\t%s\t%s\t%s\t%s\n", m.Rank, m.Title, m.Author, badge, strings.Join(m.Reasons, ", "))
+	}
+
+	return tw.Flush()
+}
+
+// Mailer sends a digest by email
+type Mailer interface {
+	Send(to []string, subject string, body []byte) error
+}
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+	// Addr is the host:port of the server
+	Addr string
+	From string
+	// Auth may be nil for servers not requiring authentication
+	Auth smtp.Auth
+}
+
+// Send implements Mailer
+func (m *SMTPMailer) Send(to []string, subject string, body []byte) error {
+	return smtp.SendMail(m.Addr, m.Auth, m.From, to, message(m.From, to, subject, body))
+}
+
+// WriterMailer writes emails to W instead of sending them,
+// as a local stand-in for an SMTP server
+type WriterMailer struct {
+	W    io.Writer
+	From string
+}
+
+// Send implements Mailer
+func (m *WriterMailer) Send(to []string, subject string, body []byte) error {
+	_, err := m.W.Write(message(m.From, to, subject, body))
+	return err
+}
+
+// message formats a plain text email
+func message(from string, to []string, subject string, body []byte) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.Write(bytes.Replace(body, []byte("\n"), []byte("\r\n"), -1))
+
+	return b.Bytes()
+}
+
+// SendDigest emails matches as a digest
+func SendDigest(m Mailer, to []string, subject string, matches []Match) error {
+	var body bytes.Buffer
+	if err := WriteDigest(&body, subject, matches); err != nil {
+		return err
+	}
+
+	return m.Send(to, subject, body.Bytes())
+}
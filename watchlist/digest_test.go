@@ -0,0 +1,50 @@
+package watchlist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDigest(t *testing.T) {
+	matches := []Match{
+		{DisplayName: "Hardcover Fiction", Rank: 4, Title: "THE INSTITUTE", Author: "Stephen King", Reasons: []string{"author Stephen King"}, FirstAppearance: true},
+		{DisplayName: "Hardcover Fiction", Rank: 1, Title: "THE GUARDIANS", Author: "John Grisham", Reasons: []string{"author John Grisham"}},
+		{DisplayName: "Audio Fiction", Rank: 2, Title: "THE GUARDIANS", Author: "John Grisham", Reasons: []string{"author John Grisham"}},
+	}
+	var buf bytes.Buffer
+	if err := WriteDigest(&buf, "Digest", matches); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	audio := strings.Index(out, "Audio Fiction")
+	guardians := strings.Index(out, "#1  THE GUARDIANS")
+	institute := strings.Index(out, "#4  THE INSTITUTE")
+	if audio < 0 || guardians < audio || institute < guardians {
+		t.Errorf("digest isn't grouped by list in rank order:\n%s", out)
+	}
+	if !strings.Contains(out[institute:], "NEW") {
+		t.Errorf("first appearance isn't flagged:\n%s", out)
+	}
+}
+
+func TestSendDigest(t *testing.T) {
+	var buf bytes.Buffer
+	m := &WriterMailer{W: &buf, From: "nytbooks@example.com"}
+	err := SendDigest(m, []string{"a@example.com", "b@example.com"}, "Digest", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"From: nytbooks@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: Digest\r\n",
+		"Nothing on the lists.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("email doesn't contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package watchlist finds the authors, ISBNs and titles of interest
+// on the best sellers lists.
+package watchlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	books "github.com/eddogola/nytimesbooks"
+	"github.com/eddogola/nytimesbooks/analytics"
+	"gopkg.in/yaml.v3"
+)
+
+// Watchlist is the set of books to look out for.
+// It is saved as JSON or YAML:
+//
+//	name: Acquisitions
+//	authors:
+//	  - Colleen Hoover
+//	isbn13s:
+//	  - "9780593321201"
+//	titles:
+//	  - dragon
+type Watchlist struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Authors match however the API spells them, e.g. "Tolkien, J.R.R."
+	// matches J. R. R. Tolkien, and co-authors match too
+	Authors []string `json:"authors,omitempty" yaml:"authors,omitempty"`
+	// ISBN13s match the primary and other ISBNs of books
+	ISBN13s []string `json:"isbn13s,omitempty" yaml:"isbn13s,omitempty"`
+	// Titles are regular expressions, matched case insensitively
+	Titles []string `json:"titles,omitempty" yaml:"titles,omitempty"`
+}
+
+// Match is a book of a list found by a Watchlist
+type Match struct {
+	List          string `json:"list"`
+	DisplayName   string `json:"display_name"`
+	PublishedDate string `json:"published_date,omitempty"`
+	Rank          int    `json:"rank"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	ISBN13        string `json:"isbn13,omitempty"`
+	// Reasons are the entries of the watchlist the book matched,
+	// e.g. "author Colleen Hoover"
+	Reasons []string `json:"reasons"`
+	// FirstAppearance is set for books that weren't on the list before.
+	// See MatchList and MatchOverview.
+	FirstAppearance bool `json:"first_appearance"`
+}
+
+// History remembers the books seen on each list, to tell first appearances
+type History map[string]bool
+
+func (h History) see(list, isbn, title string) bool {
+	key := list + "|" + isbn
+	if isbn == "" {
+		key = list + "|" + strings.ToLower(title)
+	}
+	seen := h[key]
+	h[key] = true
+
+	return seen
+}
+
+// matcher is a compiled Watchlist
+type matcher struct {
+	authors map[string]string
+	isbns   map[string]bool
+	titles  []*regexp.Regexp
+}
+
+func (w *Watchlist) compile() (*matcher, error) {
+	m := &matcher{
+		authors: make(map[string]string, len(w.Authors)),
+		isbns:   make(map[string]bool, len(w.ISBN13s)),
+	}
+	for _, a := range w.Authors {
+		m.authors[analytics.AuthorKey(a)] = a
+	}
+	for _, isbn := range w.ISBN13s {
+		m.isbns[strings.Replace(isbn, "-", "", -1)] = true
+	}
+	for _, t := range w.Titles {
+		re, err := regexp.Compile("(?i)" + t)
+		if err != nil {
+			return nil, fmt.Errorf("watchlist: title %q: %v", t, err)
+		}
+		m.titles = append(m.titles, re)
+	}
+
+	return m, nil
+}
+
+// match returns the reasons a book matches, if any
+func (m *matcher) match(title, author string, isbns ...string) []string {
+	var reasons []string
+	authors, with := analytics.SplitAuthors(author)
+	for _, a := range append(authors, with...) {
+		if name, ok := m.authors[analytics.AuthorKey(a)]; ok {
+			reasons = append(reasons, "author "+name)
+		}
+	}
+	for _, isbn := range isbns {
+		if m.isbns[isbn] {
+			reasons = append(reasons, "isbn "+isbn)
+			break
+		}
+	}
+	for _, re := range m.titles {
+		if re.MatchString(title) {
+			reasons = append(reasons, "title "+re.String()[len("(?i)"):])
+		}
+	}
+
+	return reasons
+}
+
+// Validate checks the title patterns of the watchlist
+func (w *Watchlist) Validate() error {
+	_, err := w.compile()
+	return err
+}
+
+// MatchList returns the books of a list matching the watchlist, in rank
+// order. A book's FirstAppearance is set when it's in its first week on the
+// list, and, if h isn't nil, it wasn't seen on the list before. Matches are
+// recorded in h.
+func (w *Watchlist) MatchList(list *books.ListByDate, h History) ([]Match, error) {
+	m, err := w.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, b := range list.Results.Books {
+		isbns := []string{b.PrimaryISBN13}
+		for _, isbn := range b.ISBNs {
+			isbns = append(isbns, isbn.ISBN13)
+		}
+		reasons := m.match(b.Title, b.Author, isbns...)
+		if len(reasons) == 0 {
+			continue
+		}
+		first := b.WeeksOnList <= 1
+		if h != nil && h.see(list.Results.ListName, b.PrimaryISBN13, b.Title) {
+			first = false
+		}
+		matches = append(matches, Match{
+			List:            list.Results.ListName,
+			DisplayName:     list.Results.DisplayName,
+			PublishedDate:   list.Results.PublishedDate,
+			Rank:            b.Rank,
+			Title:           b.Title,
+			Author:          b.Author,
+			ISBN13:          b.PrimaryISBN13,
+			Reasons:         reasons,
+			FirstAppearance: first,
+		})
+	}
+
+	return matches, nil
+}
+
+// MatchOverview returns the books of an overview matching the watchlist.
+// Overviews don't tell how long books have been on their list, so a book's
+// FirstAppearance is only set when h is given and it wasn't seen on the
+// list before. Matches are recorded in h.
+func (w *Watchlist) MatchOverview(o *books.Overview, h History) ([]Match, error) {
+	m, err := w.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Match
+	for _, l := range o.Results.Lists {
+		for _, b := range l.Books {
+			reasons := m.match(b.Title, b.Author, b.PrimaryISBN13)
+			if len(reasons) == 0 {
+				continue
+			}
+			matches = append(matches, Match{
+				List:            l.ListName,
+				DisplayName:     l.DisplayName,
+				PublishedDate:   o.Results.PublishedDate,
+				Rank:            b.Rank,
+				Title:           b.Title,
+				Author:          b.Author,
+				ISBN13:          b.PrimaryISBN13,
+				Reasons:         reasons,
+				FirstAppearance: h != nil && !h.see(l.ListName, b.PrimaryISBN13, b.Title),
+			})
+		}
+	}
+
+	return matches, nil
+}
+
+// Load reads a watchlist from a .json, .yaml or .yml file
+func Load(name string) (*Watchlist, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var w Watchlist
+	if isYAML(name) {
+		err = yaml.Unmarshal(data, &w)
+	} else {
+		err = json.Unmarshal(data, &w)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("watchlist: %s: %v", name, err)
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// Save writes the watchlist to a file, as YAML if its
+// extension is .yaml or .yml and as JSON otherwise
+func (w *Watchlist) Save(name string) error {
+	var data []byte
+	var err error
+	if isYAML(name) {
+		data, err = yaml.Marshal(w)
+	} else {
+		data, err = json.MarshalIndent(w, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(name, data, 0644)
+}
+
+func isYAML(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return true
+	}
+
+	return false
+}
+
+// LoadHistory reads a History saved with SaveHistory,
+// returning an empty one if the file doesn't exist
+func LoadHistory(name string) (History, error) {
+	h := make(History)
+	data, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("watchlist: history %s: %v", name, err)
+	}
+
+	return h, nil
+}
+
+// SaveHistory writes a History to a JSON file
+func SaveHistory(name string, h History) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(name, data, 0644)
+}
@@ -0,0 +1,133 @@
+package watchlist
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	books "github.com/eddogola/nytimesbooks"
+)
+
+func testList() *books.ListByDate {
+	l := &books.ListByDate{}
+	l.Results.ListName = "Hardcover Fiction"
+	l.Results.DisplayName = "Hardcover Fiction"
+	l.Results.PublishedDate = "2020-01-12"
+	l.Results.Books = []books.ListBook{
+		{Rank: 1, Title: "THE GUARDIANS", Author: "John Grisham", PrimaryISBN13: "9780385544184", WeeksOnList: 8},
+		{Rank: 2, Title: "A MINUTE TO MIDNIGHT", Author: "David Baldacci", PrimaryISBN13: "9781538761601", WeeksOnList: 1},
+		{Rank: 3, Title: "DRAGON TEETH", Author: "Michael Crichton", PrimaryISBN13: "9780062473356", WeeksOnList: 3},
+		{Rank: 4, Title: "THE INSTITUTE", Author: "Stephen King", PrimaryISBN13: "9781982110567", WeeksOnList: 1},
+	}
+
+	return l
+}
+
+func TestMatchList(t *testing.T) {
+	w := &Watchlist{
+		Authors: []string{"GRISHAM, JOHN", "David Baldacci"},
+		ISBN13s: []string{"978-1-9821-1056-7"},
+		Titles:  []string{"^dragon"},
+	}
+	h := make(History)
+	matches, err := w.MatchList(testList(), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, m := range matches {
+		got = append(got, m.Title)
+	}
+	want := []string{"THE GUARDIANS", "A MINUTE TO MIDNIGHT", "DRAGON TEETH", "THE INSTITUTE"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("matched %v, want %v", got, want)
+	}
+	if r := matches[0].Reasons; !reflect.DeepEqual(r, []string{"author GRISHAM, JOHN"}) {
+		t.Errorf("got reasons %v", r)
+	}
+	if r := matches[3].Reasons; !reflect.DeepEqual(r, []string{"isbn 9781982110567"}) {
+		t.Errorf("got reasons %v", r)
+	}
+	if matches[0].FirstAppearance || !matches[1].FirstAppearance {
+		t.Errorf("first appearances are %v and %v, want false and true",
+			matches[0].FirstAppearance, matches[1].FirstAppearance)
+	}
+
+	// a second look at the same list finds nothing new
+	matches, _ = w.MatchList(testList(), h)
+	for _, m := range matches {
+		if m.FirstAppearance {
+			t.Errorf("%s is a first appearance twice", m.Title)
+		}
+	}
+}
+
+func TestMatchOverview(t *testing.T) {
+	var o books.Overview
+	data, err := ioutil.ReadFile("../testdata/overview.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Watchlist{Authors: []string{"Justin Scott"}}
+	h := make(History)
+	matches, err := w.MatchOverview(&o, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Title != "THE GANGSTER" || !matches[0].FirstAppearance {
+		t.Errorf("got %+v, want THE GANGSTER appearing first", matches)
+	}
+	if matches, _ = w.MatchOverview(&o, h); matches[0].FirstAppearance {
+		t.Error("THE GANGSTER appeared first twice")
+	}
+}
+
+func TestInvalidTitle(t *testing.T) {
+	w := &Watchlist{Titles: []string{"(unclosed"}}
+	if err := w.Validate(); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	w := &Watchlist{
+		Name:    "Acquisitions",
+		Authors: []string{"Colleen Hoover"},
+		ISBN13s: []string{"9780593321201"},
+		Titles:  []string{"dragon"},
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"w.json", "w.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := w.Save(path); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("%s: loaded %+v, want %+v", name, got, w)
+		}
+	}
+
+	path := filepath.Join(dir, "hand.yml")
+	yml := "name: Editors\nauthors:\n  - Stephen King\nisbn13s:\n  - \"9781982110567\"\n"
+	if err := ioutil.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Editors" || len(got.Authors) != 1 || got.ISBN13s[0] != "9781982110567" {
+		t.Errorf("loaded %+v", got)
+	}
+}